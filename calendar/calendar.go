@@ -0,0 +1,338 @@
+// Package calendar decides which days are business days and adjusts job timers to them
+//
+// A Calendar has a weekend, Saturday and Sunday unless set with WithWeekend, and holidays added with WithHolidays,
+// which ReadICS and ReadJSON load from an iCalendar file or JSON. Days are read off the wall clock of the location set
+// with In, default is the location of the time in question.
+//
+// A Calendar wraps any job.Timer to handle occurrences falling on days which are not business days:
+//   - Skip drops them
+//   - RollForward runs them at the same time of day on the next business day
+//   - RollBackward runs them at the same time of day on the previous business day
+package calendar
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ahmedalhulaibi/cronalt/internal/wallclock"
+	"github.com/ahmedalhulaibi/cronalt/job"
+	"github.com/ahmedalhulaibi/cronalt/rrule"
+)
+
+// ErrInvalidCalendar is wrapped by every error returned by ReadICS and ReadJSON
+var ErrInvalidCalendar error = fmt.Errorf("invalid calendar")
+
+// maxDaySearch bounds how many days are searched for a business day
+const maxDaySearch = 5 * 366
+
+// maxSteps bounds how many occurrences of a timer on days which are not business days are walked through
+// before jumping to the next business day, and how many such jumps a timer makes looking for its next occurrence
+const maxSteps = 1024
+
+// Holiday is a day, or several consecutive days, which are not business days
+type Holiday struct {
+	Name string
+	// Date is the first day of the holiday, only its year, month and day are used
+	Date time.Time
+	// Days is how many days the holiday lasts, zero is one day
+	Days int
+
+	// recurrence repeats the holiday, e.g. yearly, set by an RRULE in an iCalendar file
+	recurrence *rrule.Set
+}
+
+func (h Holiday) days() int {
+	if h.Days < 1 {
+		return 1
+	}
+
+	return h.Days
+}
+
+// Calendar decides which days are business days
+type Calendar struct {
+	// weekend is a bitset of the weekdays which are not business days
+	weekend uint8
+	// holidays are the names of holidays by day, as midnight UTC
+	holidays map[time.Time]string
+	// recurring are the holidays which repeat
+	recurring []Holiday
+
+	loc *time.Location
+}
+
+// Option configures a Calendar
+type Option func(c *Calendar) *Calendar
+
+// WithWeekend returns an Option to set the weekdays which are not business days, default is Saturday and Sunday
+func WithWeekend(days ...time.Weekday) Option {
+	return func(c *Calendar) *Calendar {
+		c.weekend = 0
+		for _, d := range days {
+			c.weekend |= 1 << uint(d)
+		}
+
+		return c
+	}
+}
+
+// WithHolidays returns an Option to add holidays, e.g. loaded with ReadICS or ReadJSON
+func WithHolidays(holidays ...Holiday) Option {
+	return func(c *Calendar) *Calendar {
+		for _, h := range holidays {
+			if h.recurrence != nil {
+				c.recurring = append(c.recurring, h)
+				continue
+			}
+
+			first := date(h.Date)
+			for i := 0; i < h.days(); i++ {
+				c.holidays[first.AddDate(0, 0, i)] = h.Name
+			}
+		}
+
+		return c
+	}
+}
+
+// In returns an Option to read days off the wall clock of loc, default is the location of the time in question
+func In(loc *time.Location) Option {
+	return func(c *Calendar) *Calendar {
+		c.loc = loc
+		return c
+	}
+}
+
+// New returns a Calendar with a weekend of Saturday and Sunday and no holidays unless set with opts
+func New(opts ...Option) *Calendar {
+	c := &Calendar{
+		weekend:  1<<uint(time.Saturday) | 1<<uint(time.Sunday),
+		holidays: make(map[time.Time]string),
+	}
+
+	for _, opt := range opts {
+		c = opt(c)
+	}
+
+	return c
+}
+
+// IsBusinessDay reports whether the day of t is neither a weekend day nor a holiday
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	return c.isBusinessDay(c.wall(t))
+}
+
+// Holiday returns the name of the holiday on the day of t, ok is false when it is not a holiday
+func (c *Calendar) Holiday(t time.Time) (name string, ok bool) {
+	return c.holiday(date(c.wall(t)))
+}
+
+// NextBusinessDay returns the start of the first business day after the day of t, zero when there is none within a few years
+func (c *Calendar) NextBusinessDay(t time.Time) time.Time {
+	loc := c.location(t)
+
+	w, ok := c.shift(date(c.wall(t)), 1)
+	if !ok {
+		return time.Time{}
+	}
+
+	start, _ := wallclock.FromWall(w, loc, time.Time{})
+
+	return start
+}
+
+func (c *Calendar) location(t time.Time) *time.Location {
+	if c.loc != nil {
+		return c.loc
+	}
+
+	return t.Location()
+}
+
+func (c *Calendar) wall(t time.Time) time.Time {
+	return wallclock.ToWall(t, c.location(t))
+}
+
+func (c *Calendar) isBusinessDay(w time.Time) bool {
+	if c.weekend&(1<<uint(w.Weekday())) != 0 {
+		return false
+	}
+
+	_, holiday := c.holiday(date(w))
+
+	return !holiday
+}
+
+func (c *Calendar) holiday(day time.Time) (string, bool) {
+	if name, ok := c.holidays[day]; ok {
+		return name, true
+	}
+
+	for _, h := range c.recurring {
+		for i := 0; i < h.days(); i++ {
+			first := day.AddDate(0, 0, -i)
+			if next := h.recurrence.Next(first.Add(-time.Nanosecond)); !next.IsZero() && date(next).Equal(first) {
+				return h.Name, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// shift returns the wall clock time w moved to the closest business day after it, or before it when dir is negative
+func (c *Calendar) shift(w time.Time, dir int) (time.Time, bool) {
+	for i := 1; i <= maxDaySearch; i++ {
+		if d := w.AddDate(0, 0, dir*i); c.isBusinessDay(d) {
+			return d, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// roll returns t at the same time of day on the closest business day after it, or before it when dir is negative
+func (c *Calendar) roll(t time.Time, dir int) time.Time {
+	loc := c.location(t)
+
+	w, ok := c.shift(wallclock.ToWall(t, loc), dir)
+	if !ok {
+		return time.Time{}
+	}
+
+	rolled, _ := wallclock.FromWall(w, loc, time.Time{})
+
+	return rolled
+}
+
+// advance returns the occurrence of t after next, an occurrence on a day which is not a business day
+// It walks through the occurrences so timers relative to the previous run keep their phase,
+// and jumps to the next business day once the walk is too long
+func (c *Calendar) advance(t job.Timer, next time.Time, steps *int) time.Time {
+	*steps++
+	if *steps%maxSteps != 0 {
+		return t.Next(next)
+	}
+
+	opens := c.NextBusinessDay(next)
+	if opens.IsZero() {
+		return opens
+	}
+
+	return t.Next(opens.Add(-time.Nanosecond))
+}
+
+// Skip returns a timer which drops the occurrences of t on days which are not business days
+func (c *Calendar) Skip(t job.Timer) job.Timer {
+	return &timer{inner: t, cal: c, next: c.skip}
+}
+
+// RollForward returns a timer which moves the occurrences of t on days which are not business days to the same
+// time of day on the next business day, occurrences moved to the same time run once
+// An occurrence on a business day before a moved one runs instead of it
+func (c *Calendar) RollForward(t job.Timer) job.Timer {
+	return &timer{inner: t, cal: c, next: c.rollForward}
+}
+
+// RollBackward returns a timer which moves the occurrences of t on days which are not business days to the same
+// time of day on the previous business day, occurrences moved to the same time or before the previous run run once
+func (c *Calendar) RollBackward(t job.Timer) job.Timer {
+	return &timer{inner: t, cal: c, next: c.rollBackward}
+}
+
+func (c *Calendar) skip(t job.Timer, prevStart time.Time) time.Time {
+	next := t.Next(prevStart)
+
+	for steps := 0; steps < maxSteps*maxSteps && !next.IsZero(); {
+		if c.IsBusinessDay(next) {
+			return next
+		}
+
+		next = c.advance(t, next, &steps)
+	}
+
+	return time.Time{}
+}
+
+func (c *Calendar) rollForward(t job.Timer, prevStart time.Time) time.Time {
+	next := t.Next(prevStart)
+	if next.IsZero() || c.IsBusinessDay(next) {
+		return next
+	}
+
+	// A later occurrence on a business day may come before the rolled one, e.g. of a union of timers
+	rolled := c.roll(next, 1)
+	if later := c.skip(t, next); !later.IsZero() && (rolled.IsZero() || later.Before(rolled)) {
+		return later
+	}
+
+	return rolled
+}
+
+func (c *Calendar) rollBackward(t job.Timer, prevStart time.Time) time.Time {
+	next := t.Next(prevStart)
+
+	for steps := 0; steps < maxSteps*maxSteps && !next.IsZero(); {
+		if c.IsBusinessDay(next) {
+			return next
+		}
+
+		if rolled := c.roll(next, -1); rolled.After(prevStart) {
+			return rolled
+		}
+
+		next = c.advance(t, next, &steps)
+	}
+
+	return time.Time{}
+}
+
+type timer struct {
+	inner job.Timer
+	cal   *Calendar
+	next  func(t job.Timer, prevStart time.Time) time.Time
+}
+
+var (
+	_ job.Timer        = (*timer)(nil)
+	_ job.Binder       = (*timer)(nil)
+	_ job.FireObserver = (*timer)(nil)
+)
+
+// Bind binds the wrapped timer to j
+func (t *timer) Bind(j job.Job) job.Timer {
+	b, ok := t.inner.(job.Binder)
+	if !ok {
+		return t
+	}
+
+	bound := *t
+	bound.inner = b.Bind(j)
+
+	return &bound
+}
+
+// Err returns the error of the wrapped timer when it is built in steps, e.g. cronalt.DayTimer
+func (t *timer) Err() error {
+	if v, ok := t.inner.(interface{ Err() error }); ok {
+		return v.Err()
+	}
+
+	return nil
+}
+
+// Fired passes a run of the job on to the wrapped timer
+func (t *timer) Fired(at time.Time) {
+	if o, ok := t.inner.(job.FireObserver); ok {
+		o.Fired(at)
+	}
+}
+
+func (t *timer) Next(prevStart time.Time) time.Time {
+	return t.next(t.inner, prevStart)
+}
+
+// date returns the day of w on its wall clock as midnight UTC
+func date(w time.Time) time.Time {
+	return time.Date(w.Year(), w.Month(), w.Day(), 0, 0, 0, 0, time.UTC)
+}
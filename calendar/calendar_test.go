@@ -0,0 +1,285 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/ahmedalhulaibi/cronalt"
+	"github.com/ahmedalhulaibi/cronalt/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type noopJob struct {
+	name string
+}
+
+func (n noopJob) Name() string {
+	return n.name
+}
+
+func (n noopJob) Runner() job.JobFn {
+	return func(ctx context.Context) error { return nil }
+}
+
+func day(year int, month time.Month, d, hour int) time.Time {
+	return time.Date(year, month, d, hour, 0, 0, 0, time.UTC)
+}
+
+func TestCalendar_Timers(t *testing.T) {
+	// 2021-12-24 is a Friday, 2021-12-25 a Saturday
+	cal := New(WithHolidays(
+		Holiday{Name: "Christmas Day (observed)", Date: day(2021, 12, 24, 0)},
+		Holiday{Name: "Boxing Day (observed)", Date: day(2021, 12, 27, 0)},
+	))
+
+	tests := map[string]struct {
+		timer     job.Timer
+		prevStart time.Time
+		want      []time.Time
+	}{
+		"Should skip the occurrences on weekends and holidays": {
+			timer:     cal.Skip(cronalt.Daily().At("09:00")),
+			prevStart: day(2021, 12, 23, 12),
+			want:      []time.Time{day(2021, 12, 28, 9), day(2021, 12, 29, 9)},
+		},
+		"Should keep the phase of a timer relative to the previous run": {
+			timer:     cal.Skip(cronalt.Every(5 * time.Hour)),
+			prevStart: day(2021, 12, 23, 20),
+			want:      []time.Time{day(2021, 12, 28, 0), day(2021, 12, 28, 5)},
+		},
+		"Should roll the occurrences on weekends and holidays forward once": {
+			timer:     cal.RollForward(cronalt.Daily().At("09:00")),
+			prevStart: day(2021, 12, 23, 12),
+			want:      []time.Time{day(2021, 12, 28, 9), day(2021, 12, 29, 9)},
+		},
+		"Should roll an occurrence forward past consecutive holidays": {
+			timer:     cal.RollForward(cronalt.Monthly().OnDay(25).At("09:00")),
+			prevStart: day(2021, 11, 26, 0),
+			want:      []time.Time{day(2021, 12, 28, 9), day(2022, 1, 25, 9)},
+		},
+		"Should not roll an occurrence forward past an earlier occurrence on a business day": {
+			timer:     New().RollForward(cronalt.Union(cronalt.Weekly(time.Saturday).At("10:00"), cronalt.Weekly(time.Monday).At("09:00"))),
+			prevStart: day(2021, 12, 17, 12),
+			want:      []time.Time{day(2021, 12, 20, 9), day(2021, 12, 27, 9)},
+		},
+		"Should roll the occurrences on weekends and holidays backward": {
+			timer:     cal.RollBackward(cronalt.Weekly(time.Saturday).At("09:00")),
+			prevStart: day(2021, 12, 20, 0),
+			want:      []time.Time{day(2021, 12, 23, 9), day(2021, 12, 31, 9)},
+		},
+		"Should not roll an occurrence backward to the previous run or before it": {
+			timer:     cal.RollBackward(cronalt.Daily().At("09:00")),
+			prevStart: day(2021, 12, 23, 9),
+			want:      []time.Time{day(2021, 12, 28, 9)},
+		},
+		"Should finish when the timer finishes": {
+			timer:     cal.Skip(cronalt.Once(day(2021, 12, 25, 9))),
+			prevStart: day(2021, 12, 20, 0),
+			want:      []time.Time{{}},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			prev := tt.prevStart
+			for _, want := range tt.want {
+				prev = tt.timer.Next(prev)
+				require.True(t, want.Equal(prev), "want %s got %s", want, prev)
+			}
+		})
+	}
+}
+
+func TestCalendar_SkipLongStretch(t *testing.T) {
+	cal := New(WithHolidays(Holiday{Name: "Winter Break", Date: day(2021, 12, 24, 0), Days: 10}))
+
+	next := cal.Skip(cronalt.Every(time.Minute)).Next(day(2021, 12, 23, 23))
+	require.True(t, next.Before(day(2021, 12, 23, 23).Add(2*time.Minute)), next)
+
+	next = cal.Skip(cronalt.Every(time.Minute)).Next(day(2021, 12, 23, 23).Add(59 * time.Minute))
+	assert.True(t, cal.IsBusinessDay(next), next)
+	assert.True(t, next.Before(day(2022, 1, 4, 0).Add(time.Minute)), next)
+}
+
+func TestCalendar_IsBusinessDay(t *testing.T) {
+	toronto, err := time.LoadLocation("America/Toronto")
+	require.NoError(t, err)
+
+	// 2021-12-24 20:00 in Toronto is 2021-12-25 01:00 UTC
+	friday := time.Date(2021, 12, 24, 20, 0, 0, 0, toronto)
+
+	tests := map[string]struct {
+		cal  *Calendar
+		t    time.Time
+		want bool
+	}{
+		"Should be a business day on a weekday":                {cal: New(), t: friday, want: true},
+		"Should not be a business day on a weekend":            {cal: New(), t: friday.AddDate(0, 0, 1), want: false},
+		"Should read the day off the wall clock of a location": {cal: New(In(time.UTC)), t: friday, want: false},
+		"Should not be a business day on a custom weekend":     {cal: New(WithWeekend(time.Friday, time.Saturday)), t: friday, want: false},
+		"Should be a business day on a default weekend day":    {cal: New(WithWeekend(time.Friday, time.Saturday)), t: friday.AddDate(0, 0, 2), want: true},
+		"Should not be a business day on a holiday": {
+			cal:  New(WithHolidays(Holiday{Name: "Christmas Eve", Date: day(2021, 12, 24, 0)})),
+			t:    friday,
+			want: false,
+		},
+		"Should not be a business day on the last day of a holiday": {
+			cal:  New(WithHolidays(Holiday{Name: "Winter Break", Date: day(2021, 12, 20, 0), Days: 5})),
+			t:    friday,
+			want: false,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.cal.IsBusinessDay(tt.t))
+		})
+	}
+}
+
+func TestCalendar_NextBusinessDay(t *testing.T) {
+	toronto, err := time.LoadLocation("America/Toronto")
+	require.NoError(t, err)
+
+	cal := New(In(toronto))
+
+	assert.True(t, time.Date(2021, 12, 27, 0, 0, 0, 0, toronto).Equal(cal.NextBusinessDay(day(2021, 12, 24, 12))))
+	assert.True(t, New(WithWeekend(time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday)).
+		NextBusinessDay(day(2021, 12, 24, 12)).IsZero())
+}
+
+const holidaysICS = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART;VALUE=DATE:20000101\r\n" +
+	"RRULE:FREQ=YEARLY\r\n" +
+	"SUMMARY:New Year's Day\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART;VALUE=DATE:20211224\r\n" +
+	"DTEND;VALUE=DATE:20211229\r\n" +
+	"SUMMARY:Winter\r\n" +
+	"  Break\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART;TZID=America/Toronto:20210705T093000\r\n" +
+	"DTEND;TZID=America/Toronto:20210706T120000\r\n" +
+	"SUMMARY:Market Closure\\, Systems Upgrade\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestReadICS(t *testing.T) {
+	holidays, err := ReadICS(strings.NewReader(holidaysICS))
+	require.NoError(t, err)
+	require.Len(t, holidays, 3)
+
+	cal := New(WithHolidays(holidays...))
+
+	tests := map[string]struct {
+		t        time.Time
+		wantName string
+		wantOK   bool
+	}{
+		"Should repeat a holiday with an RRULE":              {t: day(2031, 1, 1, 12), wantName: "New Year's Day", wantOK: true},
+		"Should start a holiday on DTSTART":                  {t: day(2021, 12, 24, 12), wantName: "Winter Break", wantOK: true},
+		"Should end a holiday the day before a DATE DTEND":   {t: day(2021, 12, 28, 12), wantName: "Winter Break", wantOK: true},
+		"Should not include a DATE DTEND":                    {t: day(2021, 12, 29, 12)},
+		"Should include the day of a DATE-TIME DTEND":        {t: day(2021, 7, 6, 12), wantName: "Market Closure, Systems Upgrade", wantOK: true},
+		"Should not repeat a holiday without an RRULE":       {t: day(2022, 12, 24, 12)},
+		"Should not include the day before a holiday starts": {t: day(2030, 12, 31, 12)},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			name, ok := cal.Holiday(tt.t)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantName, name)
+		})
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	holidays, err := ReadJSON(strings.NewReader(`[
+		{"name": "Christmas Day", "date": "2021-12-25"},
+		{"name": "Golden Week", "date": "2021-05-01", "days": 5}
+	]`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []Holiday{
+		{Name: "Christmas Day", Date: day(2021, 12, 25, 0)},
+		{Name: "Golden Week", Date: day(2021, 5, 1, 0), Days: 5},
+	}, holidays)
+}
+
+func TestRead_Errors(t *testing.T) {
+	tests := map[string]func() error{
+		"Should reject malformed JSON": func() error {
+			_, err := ReadJSON(strings.NewReader(`{"name": "Christmas Day"}`))
+			return err
+		},
+		"Should reject an invalid JSON date": func() error {
+			_, err := ReadJSON(strings.NewReader(`[{"name": "Christmas Day", "date": "25/12/2021"}]`))
+			return err
+		},
+		"Should reject negative days": func() error {
+			_, err := ReadJSON(strings.NewReader(`[{"name": "Christmas Day", "date": "2021-12-25", "days": -1}]`))
+			return err
+		},
+		"Should reject an event without DTSTART": func() error {
+			_, err := ReadICS(strings.NewReader("BEGIN:VEVENT\nSUMMARY:Christmas Day\nEND:VEVENT"))
+			return err
+		},
+		"Should reject an unterminated event": func() error {
+			_, err := ReadICS(strings.NewReader("BEGIN:VEVENT\nDTSTART;VALUE=DATE:20211225"))
+			return err
+		},
+		"Should reject an invalid RRULE": func() error {
+			_, err := ReadICS(strings.NewReader("BEGIN:VEVENT\nDTSTART;VALUE=DATE:20211225\nRRULE:FREQ=SOMETIMES\nEND:VEVENT"))
+			return err
+		},
+	}
+	for name, read := range tests {
+		read := read
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.True(t, errors.Is(read(), ErrInvalidCalendar))
+		})
+	}
+}
+
+func TestCalendar_TimerBind(t *testing.T) {
+	startFixture := day(2021, 12, 20, 12)
+	skip := New().Skip(cronalt.Times(1, cronalt.Every(time.Hour))).(job.Binder)
+
+	first := skip.Bind(noopJob{name: "first"})
+	assert.Equal(t, startFixture.Add(time.Hour), first.Next(startFixture))
+	first.(job.FireObserver).Fired(startFixture.Add(time.Hour))
+	assert.True(t, first.Next(startFixture.Add(time.Hour)).IsZero())
+
+	second := skip.Bind(noopJob{name: "second"})
+	assert.Equal(t, startFixture.Add(time.Hour), second.Next(startFixture))
+}
+
+func TestCalendar_TimerErr(t *testing.T) {
+	s, err := cronalt.NewScheduler(1)
+	require.NoError(t, err)
+
+	err = s.Schedule(New().RollForward(cronalt.Weekly()), noopJob{name: "invalid"})
+	assert.True(t, errors.Is(err, cronalt.ErrInvalidDayTimer))
+}
@@ -0,0 +1,206 @@
+package calendar
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ahmedalhulaibi/cronalt/rrule"
+)
+
+const (
+	dateLayout    = "2006-01-02"
+	icsDateLayout = "20060102"
+)
+
+type jsonHoliday struct {
+	Name string `json:"name"`
+	Date string `json:"date"`
+	Days int    `json:"days"`
+}
+
+// ReadJSON reads holidays from a JSON array, days is optional and defaults to one day, e.g.
+//
+//	[{"name": "Christmas Day", "date": "2021-12-25"}, {"name": "Golden Week", "date": "2021-05-01", "days": 5}]
+func ReadJSON(r io.Reader) ([]Holiday, error) {
+	var raw []jsonHoliday
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%w:%s", ErrInvalidCalendar, err)
+	}
+
+	holidays := make([]Holiday, 0, len(raw))
+	for _, h := range raw {
+		d, err := time.Parse(dateLayout, h.Date)
+		if err != nil {
+			return nil, fmt.Errorf("%w:%s", ErrInvalidCalendar, err)
+		}
+
+		if h.Days < 0 {
+			return nil, fmt.Errorf("%w:negative days for %s", ErrInvalidCalendar, h.Name)
+		}
+
+		holidays = append(holidays, Holiday{Name: h.Name, Date: d, Days: h.Days})
+	}
+
+	return holidays, nil
+}
+
+// ReadICS reads holidays from the VEVENTs of an iCalendar (RFC 5545) file, e.g. one published by an exchange
+// The SUMMARY of an event is the name of its holiday and DTSTART its first day, a DTEND spans it over several days
+// An event with an RRULE, RDATE or EXDATE repeats, e.g. RRULE:FREQ=YEARLY for a holiday on a fixed date
+func ReadICS(r io.Reader) ([]Holiday, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w:%s", ErrInvalidCalendar, err)
+	}
+
+	var (
+		holidays []Holiday
+		event    *icsEvent
+	)
+
+	for _, l := range lines {
+		name, value := splitLine(l)
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			event = &icsEvent{}
+		case name == "END" && value == "VEVENT":
+			if event == nil {
+				return nil, fmt.Errorf("%w:%s", ErrInvalidCalendar, "END:VEVENT without BEGIN:VEVENT")
+			}
+
+			h, err := event.holiday()
+			if err != nil {
+				return nil, err
+			}
+
+			holidays = append(holidays, h)
+			event = nil
+		case event == nil:
+		case name == "SUMMARY":
+			event.summary = unescape(value)
+		case name == "DTSTART":
+			event.dtstart, event.start = l, value
+		case name == "DTEND":
+			event.end = value
+		case name == "RRULE" || name == "RDATE" || name == "EXDATE":
+			event.recurrence = append(event.recurrence, l)
+		}
+	}
+
+	if event != nil {
+		return nil, fmt.Errorf("%w:%s", ErrInvalidCalendar, "BEGIN:VEVENT without END:VEVENT")
+	}
+
+	return holidays, nil
+}
+
+type icsEvent struct {
+	summary string
+	// dtstart is the DTSTART content line and start its value
+	dtstart, start string
+	end            string
+	// recurrence are the RRULE, RDATE and EXDATE content lines
+	recurrence []string
+}
+
+func (e *icsEvent) holiday() (Holiday, error) {
+	if e.start == "" {
+		return Holiday{}, fmt.Errorf("%w:missing DTSTART for %s", ErrInvalidCalendar, e.summary)
+	}
+
+	start, err := parseDate(e.start)
+	if err != nil {
+		return Holiday{}, err
+	}
+
+	h := Holiday{Name: e.summary, Date: start, Days: 1}
+
+	if e.end != "" {
+		end, err := parseDate(e.end)
+		if err != nil {
+			return Holiday{}, err
+		}
+
+		// A DATE DTEND is the day after the event, a DATE-TIME DTEND is inside of the last day unless it is midnight
+		h.Days = int(end.Sub(start).Hours() / 24)
+		if len(e.end) > len(icsDateLayout) && !strings.HasSuffix(strings.TrimSuffix(e.end, "Z"), "T000000") {
+			h.Days++
+		}
+
+		if h.Days < 1 {
+			h.Days = 1
+		}
+	}
+
+	if len(e.recurrence) > 0 {
+		set, err := rrule.Parse(e.dtstart + "\n" + strings.Join(e.recurrence, "\n"))
+		if err != nil {
+			return Holiday{}, fmt.Errorf("%w:%s", ErrInvalidCalendar, err)
+		}
+
+		h.recurrence = set
+	}
+
+	return h, nil
+}
+
+// parseDate parses the day of a DATE or DATE-TIME value
+func parseDate(v string) (time.Time, error) {
+	if len(v) < len(icsDateLayout) {
+		return time.Time{}, fmt.Errorf("%w:invalid date %s", ErrInvalidCalendar, v)
+	}
+
+	d, err := time.Parse(icsDateLayout, v[:len(icsDateLayout)])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w:%s", ErrInvalidCalendar, err)
+	}
+
+	return d, nil
+}
+
+// unfold returns the content lines of an iCalendar file, joining the lines folded onto a line starting with white space
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		l := strings.TrimRight(scanner.Text(), "\r")
+		if l == "" {
+			continue
+		}
+
+		if (l[0] == ' ' || l[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += l[1:]
+			continue
+		}
+
+		lines = append(lines, l)
+	}
+
+	return lines, scanner.Err()
+}
+
+// splitLine splits a content line, NAME;PARAM=VALUE:VALUE, into its name and value
+func splitLine(l string) (name, value string) {
+	i := strings.Index(l, ":")
+	if i < 0 {
+		return strings.ToUpper(l), ""
+	}
+
+	name, value = l[:i], l[i+1:]
+	if j := strings.Index(name, ";"); j >= 0 {
+		name = name[:j]
+	}
+
+	return strings.ToUpper(name), value
+}
+
+// unescape replaces the escaped characters of a TEXT value
+func unescape(v string) string {
+	return strings.NewReplacer(`\,`, ",", `\;`, ";", `\n`, " ", `\N`, " ", `\\`, `\`).Replace(v)
+}
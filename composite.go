@@ -0,0 +1,312 @@
+package cronalt
+
+import (
+	"time"
+
+	"github.com/ahmedalhulaibi/cronalt/job"
+)
+
+// maxCompositeSteps bounds how many windows, or rounds of Intersect, a composite timer goes through looking for
+// its next occurrence before it gives up and reports no further runs
+const maxCompositeSteps = 1024
+
+// Window is a recurring span of time, each occurrence of its start timer opens a window which lasts for its duration
+// The start timer should not depend on the previous run, e.g. a cron expression or a DayTimer
+type Window struct {
+	start job.Timer
+	d     time.Duration
+}
+
+// NewWindow returns a Window opened by each occurrence of start which lasts for d
+// e.g. NewWindow(Daily().At("02:00"), time.Hour) is every day from 02:00 until 03:00
+func NewWindow(start job.Timer, d time.Duration) Window {
+	return Window{start: start, d: d}
+}
+
+func (w Window) bind(j job.Job) Window {
+	w.start = bind(w.start, j)
+	return w
+}
+
+// end returns when the window containing t closes, including the windows overlapping it, in is false when no window contains t
+// A window contains the time it opens and not the time it closes
+func (w Window) end(t time.Time) (end time.Time, in bool) {
+	if w.d <= 0 {
+		return time.Time{}, false
+	}
+
+	opened := w.start.Next(t.Add(-w.d))
+	if opened.IsZero() || opened.After(t) {
+		return time.Time{}, false
+	}
+
+	end = opened.Add(w.d)
+
+	next := w.start.Next(opened)
+	for i := 0; i < maxMisfireSteps && !next.IsZero() && !next.After(end); i++ {
+		if e := next.Add(w.d); e.After(end) {
+			end = e
+		}
+
+		next = w.start.Next(next)
+	}
+
+	return end, true
+}
+
+type unionTimer struct {
+	timers []job.Timer
+}
+
+var (
+	_ jobTimer         = (*unionTimer)(nil)
+	_ job.Binder       = (*unionTimer)(nil)
+	_ job.FireObserver = (*unionTimer)(nil)
+	_ validator        = (*unionTimer)(nil)
+)
+
+// Union returns a timer which runs at the occurrences of every one of the timers, e.g. the 1st of the month or any Monday
+// An occurrence shared by several timers runs once
+func Union(a job.Timer, b ...job.Timer) unionTimer {
+	return unionTimer{timers: append([]job.Timer{a}, b...)}
+}
+
+// Bind binds the timers to j
+func (u unionTimer) Bind(j job.Job) job.Timer {
+	timers := make([]job.Timer, len(u.timers))
+	for i, t := range u.timers {
+		timers[i] = bind(t, j)
+	}
+
+	return unionTimer{timers: timers}
+}
+
+// Err returns the first error of the timers built in steps, e.g. DayTimer
+func (u unionTimer) Err() error {
+	return timersErr(u.timers...)
+}
+
+// Fired passes a run of the job on to the timers
+func (u unionTimer) Fired(at time.Time) {
+	for _, t := range u.timers {
+		fired(t, at)
+	}
+}
+
+func (u unionTimer) Next(prevStart time.Time) time.Time {
+	var next time.Time
+
+	for _, t := range u.timers {
+		n := t.Next(prevStart)
+		if !n.IsZero() && (next.IsZero() || n.Before(next)) {
+			next = n
+		}
+	}
+
+	return next
+}
+
+type intersectTimer struct {
+	timers []job.Timer
+}
+
+var (
+	_ jobTimer         = (*intersectTimer)(nil)
+	_ job.Binder       = (*intersectTimer)(nil)
+	_ job.FireObserver = (*intersectTimer)(nil)
+	_ validator        = (*intersectTimer)(nil)
+)
+
+// Intersect returns a timer which only runs at the occurrences shared by all of the timers
+// e.g. a cron expression for the 13th of the month and Weekly(time.Friday) for every Friday the 13th
+// The timers should not depend on the previous run, like the start timer of a Window
+func Intersect(a job.Timer, b ...job.Timer) intersectTimer {
+	return intersectTimer{timers: append([]job.Timer{a}, b...)}
+}
+
+// Bind binds the timers to j
+func (it intersectTimer) Bind(j job.Job) job.Timer {
+	timers := make([]job.Timer, len(it.timers))
+	for i, t := range it.timers {
+		timers[i] = bind(t, j)
+	}
+
+	return intersectTimer{timers: timers}
+}
+
+// Err returns the first error of the timers built in steps, e.g. DayTimer
+func (it intersectTimer) Err() error {
+	return timersErr(it.timers...)
+}
+
+// Fired passes a run of the job on to the timers
+func (it intersectTimer) Fired(at time.Time) {
+	for _, t := range it.timers {
+		fired(t, at)
+	}
+}
+
+func (it intersectTimer) Next(prevStart time.Time) time.Time {
+	next := it.timers[0].Next(prevStart)
+
+	// Leapfrog every timer to its first occurrence at or after the latest candidate until they agree
+	for i := 0; i < maxCompositeSteps && !next.IsZero(); i++ {
+		agreed := true
+
+		for _, t := range it.timers {
+			n := t.Next(next.Add(-time.Nanosecond))
+			if n.IsZero() {
+				return n
+			}
+
+			if n.After(next) {
+				next, agreed = n, false
+			}
+		}
+
+		if agreed {
+			return next
+		}
+	}
+
+	return time.Time{}
+}
+
+type exceptTimer struct {
+	inner  job.Timer
+	window Window
+}
+
+var (
+	_ jobTimer         = (*exceptTimer)(nil)
+	_ job.Binder       = (*exceptTimer)(nil)
+	_ job.FireObserver = (*exceptTimer)(nil)
+	_ validator        = (*exceptTimer)(nil)
+)
+
+// Except returns a timer which runs at the occurrences of t outside of window
+// e.g. Except(Every(5*time.Minute), NewWindow(Daily().At("02:00"), time.Hour)) skips a nightly maintenance window
+func Except(t job.Timer, window Window) exceptTimer {
+	return exceptTimer{inner: t, window: window}
+}
+
+// Bind binds t and the start timer of the window to j
+func (e exceptTimer) Bind(j job.Job) job.Timer {
+	return exceptTimer{inner: bind(e.inner, j), window: e.window.bind(j)}
+}
+
+// Err returns the first error of the timers built in steps, e.g. DayTimer
+func (e exceptTimer) Err() error {
+	return timersErr(e.inner, e.window.start)
+}
+
+// Fired passes a run of the job on to t
+func (e exceptTimer) Fired(at time.Time) {
+	fired(e.inner, at)
+}
+
+func (e exceptTimer) Next(prevStart time.Time) time.Time {
+	next := e.inner.Next(prevStart)
+
+	for i := 0; i < maxCompositeSteps && !next.IsZero(); i++ {
+		end, in := e.window.end(next)
+		if !in {
+			return next
+		}
+
+		next = stepUntil(e.inner, next, end)
+	}
+
+	return time.Time{}
+}
+
+type withinTimer struct {
+	inner  job.Timer
+	window Window
+}
+
+var (
+	_ jobTimer         = (*withinTimer)(nil)
+	_ job.Binder       = (*withinTimer)(nil)
+	_ job.FireObserver = (*withinTimer)(nil)
+	_ validator        = (*withinTimer)(nil)
+)
+
+// Within returns a timer which only runs at the occurrences of t inside of window
+// e.g. Within(Every(time.Minute), NewWindow(Weekdays().At("09:00"), 8*time.Hour)) runs every minute during office hours
+func Within(t job.Timer, window Window) withinTimer {
+	return withinTimer{inner: t, window: window}
+}
+
+// Bind binds t and the start timer of the window to j
+func (w withinTimer) Bind(j job.Job) job.Timer {
+	return withinTimer{inner: bind(w.inner, j), window: w.window.bind(j)}
+}
+
+// Err returns the first error of the timers built in steps, e.g. DayTimer
+func (w withinTimer) Err() error {
+	return timersErr(w.inner, w.window.start)
+}
+
+// Fired passes a run of the job on to t
+func (w withinTimer) Fired(at time.Time) {
+	fired(w.inner, at)
+}
+
+func (w withinTimer) Next(prevStart time.Time) time.Time {
+	next := w.inner.Next(prevStart)
+
+	for i := 0; i < maxCompositeSteps && !next.IsZero(); i++ {
+		if _, in := w.window.end(next); in {
+			return next
+		}
+
+		// next is outside of every window so the next one opens after it
+		opens := w.window.start.Next(next)
+		if opens.IsZero() {
+			return opens
+		}
+
+		next = stepUntil(w.inner, next, opens)
+	}
+
+	return time.Time{}
+}
+
+// stepUntil returns the first occurrence of t at or after until starting from its occurrence from
+// It walks through the occurrences so timers relative to the previous run keep their phase and jumps once the walk is too long
+func stepUntil(t job.Timer, from, until time.Time) time.Time {
+	next := from
+
+	for i := 0; !next.IsZero() && next.Before(until); i++ {
+		if i == maxMisfireSteps {
+			return t.Next(until.Add(-time.Nanosecond))
+		}
+
+		next = t.Next(next)
+	}
+
+	return next
+}
+
+// bind binds t to j when it is a job.Binder
+func bind(t job.Timer, j job.Job) job.Timer {
+	if b, ok := t.(job.Binder); ok {
+		return b.Bind(j)
+	}
+
+	return t
+}
+
+// timersErr returns the first error of the timers which are a validator
+func timersErr(timers ...job.Timer) error {
+	for _, t := range timers {
+		if v, ok := t.(validator); ok {
+			if err := v.Err(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package cronalt
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ahmedalhulaibi/cronalt/cron"
+	"github.com/ahmedalhulaibi/cronalt/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompositeTimers(t *testing.T) {
+	fridayThe13th, err := cron.Parse("0 9 13 * *")
+	require.NoError(t, err)
+
+	// 2021-01-01 is a Friday
+	startFixture := time.Date(2021, 01, 01, 12, 0, 0, 0, time.UTC)
+	maintenance := NewWindow(Daily().At("02:00"), time.Hour)
+
+	tests := map[string]struct {
+		timer     job.Timer
+		prevStart time.Time
+		want      []time.Time
+	}{
+		"Should run at the occurrences of every timer of a union once": {
+			timer:     Union(Monthly(), Weekly(time.Monday)),
+			prevStart: startFixture,
+			want: []time.Time{
+				time.Date(2021, 01, 04, 0, 0, 0, 0, time.UTC),
+				time.Date(2021, 01, 11, 0, 0, 0, 0, time.UTC),
+				time.Date(2021, 01, 18, 0, 0, 0, 0, time.UTC),
+				time.Date(2021, 01, 25, 0, 0, 0, 0, time.UTC),
+				time.Date(2021, 02, 01, 0, 0, 0, 0, time.UTC),
+				time.Date(2021, 02, 8, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should finish a union once every timer finished": {
+			timer:     Union(Once(startFixture.Add(time.Hour)), Once(startFixture.Add(2*time.Hour))),
+			prevStart: startFixture,
+			want:      []time.Time{startFixture.Add(time.Hour), startFixture.Add(2 * time.Hour), {}},
+		},
+		"Should only run at the occurrences shared by an intersection": {
+			timer:     Intersect(fridayThe13th, Weekly(time.Friday).At("09:00")),
+			prevStart: startFixture,
+			want: []time.Time{
+				time.Date(2021, 8, 13, 9, 0, 0, 0, time.UTC),
+				time.Date(2022, 5, 13, 9, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should finish an intersection without shared occurrences": {
+			timer:     Intersect(Weekly(time.Monday), Weekly(time.Tuesday)),
+			prevStart: startFixture,
+			want:      []time.Time{{}},
+		},
+		"Should skip the occurrences inside of a window": {
+			timer:     Except(Every(30*time.Minute), maintenance),
+			prevStart: time.Date(2021, 01, 01, 1, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 01, 01, 1, 30, 0, 0, time.UTC),
+				time.Date(2021, 01, 01, 3, 0, 0, 0, time.UTC),
+				time.Date(2021, 01, 01, 3, 30, 0, 0, time.UTC),
+			},
+		},
+		"Should keep the phase of a timer relative to the previous run across a window": {
+			timer:     Except(Every(25*time.Minute), maintenance),
+			prevStart: time.Date(2021, 01, 01, 1, 50, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 01, 01, 3, 5, 0, 0, time.UTC),
+			},
+		},
+		"Should skip overlapping windows": {
+			timer:     Except(Every(time.Hour), NewWindow(Union(Daily().At("02:00"), Daily().At("02:30")), time.Hour)),
+			prevStart: time.Date(2021, 01, 01, 1, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 01, 01, 4, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should finish when every occurrence is inside of a window": {
+			timer:     Except(Every(time.Hour), NewWindow(Daily(), 48*time.Hour)),
+			prevStart: startFixture,
+			want:      []time.Time{{}},
+		},
+		"Should only run the occurrences inside of a window": {
+			timer:     Within(Every(time.Hour), NewWindow(Weekdays().At("09:00"), 3*time.Hour)),
+			prevStart: startFixture,
+			want: []time.Time{
+				time.Date(2021, 01, 04, 9, 0, 0, 0, time.UTC),
+				time.Date(2021, 01, 04, 10, 0, 0, 0, time.UTC),
+				time.Date(2021, 01, 04, 11, 0, 0, 0, time.UTC),
+				time.Date(2021, 01, 05, 9, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should finish when no window opens anymore": {
+			timer:     Within(Every(time.Hour), NewWindow(Once(startFixture.Add(-time.Hour)), time.Hour)),
+			prevStart: startFixture,
+			want:      []time.Time{{}},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			prev := tt.prevStart
+			for _, want := range tt.want {
+				prev = tt.timer.Next(prev)
+				require.True(t, want.Equal(prev), "want %s got %s", want, prev)
+			}
+		})
+	}
+}
+
+func TestCompositeTimers_Bind(t *testing.T) {
+	startFixture := time.Date(2021, 01, 01, 12, 0, 0, 0, time.UTC)
+	union := Union(Times(1, Every(time.Hour)))
+
+	first := union.Bind(signalJob{name: "first"})
+	assert.Equal(t, startFixture.Add(time.Hour), first.Next(startFixture))
+	fired(first, startFixture.Add(time.Hour))
+	assert.True(t, first.Next(startFixture.Add(time.Hour)).IsZero())
+
+	second := union.Bind(signalJob{name: "second"})
+	assert.Equal(t, startFixture.Add(time.Hour), second.Next(startFixture))
+}
+
+func TestCompositeTimers_Err(t *testing.T) {
+	tests := map[string]job.Timer{
+		"Should reject a union of an invalid timer":         Union(Every(time.Hour), Weekly()),
+		"Should reject an intersection of an invalid timer": Intersect(Weekly(), Every(time.Hour)),
+		"Should reject an invalid window":                   Except(Every(time.Hour), NewWindow(Daily().At("25:00"), time.Hour)),
+		"Should reject an invalid timer within a window":    Within(Monthly().OnDay(0), NewWindow(Daily(), time.Hour)),
+	}
+	for name, timer := range tests {
+		timer := timer
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := NewScheduler(1)
+			require.NoError(t, err)
+			assert.True(t, errors.Is(s.Schedule(timer, signalJob{name: "invalid"}), ErrInvalidDayTimer))
+		})
+	}
+}
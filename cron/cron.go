@@ -0,0 +1,142 @@
+// Package cron parses cron expressions into job timers
+//
+// Supported syntaxes:
+//   - standard 5 fields: minute hour day-of-month month day-of-week, e.g. "30 2 * * MON-FRI"
+//   - 6 fields with a leading seconds field, e.g. "*/15 * * * * *"
+//   - descriptors: @yearly (@annually), @monthly, @weekly, @daily (@midnight), @hourly and @every <duration>, e.g. "@every 5m"
+//
+// Any expression may be prefixed with "CRON_TZ=<zone> " (or "TZ=<zone> ") to evaluate it on the wall clock of an IANA time zone.
+//
+// Fields accept *, single values, ranges (1-5), lists (1,15,30) and steps (*/5, 10-40/10, 5/15).
+// Months and days of the week accept case insensitive names (JAN, MON), day-of-week accepts 0 and 7 for Sunday
+// and ? is accepted as * in the day fields. When both day fields are restricted a day matching either runs.
+//
+// Hashed fields spread jobs sharing a schedule across a window, as in Jenkins. H picks a stable value in the field
+// derived from the job name, e.g. "H * * * *" runs hourly at a minute specific to the job. H(0-29) restricts the value
+// to a range and H/15 or H(0-29)/5 picks the offset of a step. Day-of-month hashes within 1-28 and day-of-week within 0-6.
+// The Scheduler binds hashed expressions to the job they are scheduled with, see Schedule.Bind.
+package cron
+
+import (
+	"time"
+
+	"github.com/ahmedalhulaibi/cronalt/internal/wallclock"
+	"github.com/ahmedalhulaibi/cronalt/job"
+)
+
+// searchYears bounds how far ahead Next searches for an expression which never matches, e.g. "0 0 30 2 *"
+const searchYears = 5
+
+// Schedule is a parsed cron expression
+type Schedule struct {
+	expr string
+	// hashed records whether the expression has a hashed field whose value depends on the job it is bound to
+	hashed bool
+
+	second, minute, hour, dom, month, dow uint64
+	// domAny and dowAny record whether the day fields are unrestricted
+	domAny, dowAny bool
+
+	// every is the interval of an @every expression
+	every time.Duration
+	// loc is the time zone the expression is evaluated in, nil uses the location of the previous run
+	loc *time.Location
+}
+
+var (
+	_ job.Timer  = (*Schedule)(nil)
+	_ job.Binder = (*Schedule)(nil)
+)
+
+// Bind returns the Schedule with hashed fields derived from the name of j
+// A Schedule which is not bound hashes an empty name
+func (s *Schedule) Bind(j job.Job) job.Timer {
+	return s.hash(j.Name())
+}
+
+func (s *Schedule) hash(key string) *Schedule {
+	if !s.hashed {
+		return s
+	}
+
+	p := &parser{expr: s.expr, key: key}
+
+	b, err := p.parse()
+	if err != nil {
+		// unreachable since the expression already parsed
+		return s
+	}
+
+	if b.loc == nil {
+		b.loc = s.loc
+	}
+
+	return b
+}
+
+// Next returns the first time matching the expression after prevStart, zero if there is none within the next few years
+func (s *Schedule) Next(prevStart time.Time) time.Time {
+	if s.every > 0 {
+		return prevStart.Add(s.every)
+	}
+
+	loc := s.loc
+	if loc == nil {
+		loc = prevStart.Location()
+	}
+
+	return wallclock.In(wallTimer{s}, loc).Next(prevStart)
+}
+
+// Location returns the time zone set by a CRON_TZ prefix or ParseInLocation, nil when the expression is evaluated
+// in the location of the previous run
+func (s *Schedule) Location() *time.Location {
+	return s.loc
+}
+
+// wallTimer matches the expression against wall clock times
+type wallTimer struct {
+	*Schedule
+}
+
+func (s wallTimer) Next(w time.Time) time.Time {
+	t := w.Truncate(time.Second).Add(time.Second)
+	limit := t.Year() + searchYears
+
+	for t.Year() <= limit {
+		switch {
+		case !has(s.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case !has(s.hour, t.Hour()):
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case !has(s.minute, t.Minute()):
+			t = t.Truncate(time.Minute).Add(time.Minute)
+		case !has(s.second, t.Second()):
+			t = t.Add(time.Second)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := has(s.dom, t.Day())
+	dow := has(s.dow, int(t.Weekday()))
+
+	switch {
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+func has(bits uint64, v int) bool {
+	return bits&(1<<uint(v)) != 0
+}
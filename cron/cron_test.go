@@ -0,0 +1,326 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/ahmedalhulaibi/cronalt/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	toronto, err := time.LoadLocation("America/Toronto")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		expr      string
+		prevStart time.Time
+		want      []time.Time
+	}{
+		"Should run every minute": {
+			expr:      "* * * * *",
+			prevStart: time.Date(2021, 01, 01, 0, 0, 30, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 01, 01, 0, 1, 0, 0, time.UTC),
+				time.Date(2021, 01, 01, 0, 2, 0, 0, time.UTC),
+			},
+		},
+		"Should run every 15 seconds with a seconds field": {
+			expr:      "*/15 * * * * *",
+			prevStart: time.Date(2021, 01, 01, 0, 0, 50, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 01, 01, 0, 1, 0, 0, time.UTC),
+				time.Date(2021, 01, 01, 0, 1, 15, 0, time.UTC),
+			},
+		},
+		"Should run on lists ranges and steps": {
+			expr:      "5,10-20/5 9 * * *",
+			prevStart: time.Date(2021, 01, 01, 9, 5, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 01, 01, 9, 10, 0, 0, time.UTC),
+				time.Date(2021, 01, 01, 9, 15, 0, 0, time.UTC),
+				time.Date(2021, 01, 01, 9, 20, 0, 0, time.UTC),
+				time.Date(2021, 01, 02, 9, 5, 0, 0, time.UTC),
+			},
+		},
+		"Should run from a value to the end of the field with a step": {
+			expr:      "50/5 * * * *",
+			prevStart: time.Date(2021, 01, 01, 9, 56, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 01, 01, 10, 50, 0, 0, time.UTC),
+				time.Date(2021, 01, 01, 10, 55, 0, 0, time.UTC),
+			},
+		},
+		"Should run on named weekdays": {
+			expr:      "0 9 * * mon-FRI",
+			prevStart: time.Date(2021, 01, 01, 9, 0, 0, 0, time.UTC), // Friday
+			want: []time.Time{
+				time.Date(2021, 01, 04, 9, 0, 0, 0, time.UTC),
+				time.Date(2021, 01, 05, 9, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should treat 7 as Sunday": {
+			expr:      "0 0 * * 7",
+			prevStart: time.Date(2021, 01, 01, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 01, 03, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should run on either day field when both are restricted": {
+			expr:      "0 0 15 * MON",
+			prevStart: time.Date(2021, 02, 10, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 02, 15, 0, 0, 0, 0, time.UTC),
+				time.Date(2021, 02, 22, 0, 0, 0, 0, time.UTC),
+				time.Date(2021, 03, 01, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should run on named months and skip short months": {
+			expr:      "0 0 31 jan-mar *",
+			prevStart: time.Date(2021, 01, 31, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 03, 31, 0, 0, 0, 0, time.UTC),
+				time.Date(2022, 01, 31, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should run on leap days": {
+			expr:      "0 0 29 2 ?",
+			prevStart: time.Date(2021, 01, 01, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2024, 02, 29, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should return zero when the expression never matches": {
+			expr:      "0 0 30 2 *",
+			prevStart: time.Date(2021, 01, 01, 0, 0, 0, 0, time.UTC),
+			want:      []time.Time{{}},
+		},
+		"Should run hourly": {
+			expr:      "@hourly",
+			prevStart: time.Date(2021, 01, 01, 0, 30, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 01, 01, 1, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should run daily": {
+			expr:      "@daily",
+			prevStart: time.Date(2021, 01, 01, 0, 30, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 01, 02, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should run weekly on Sunday": {
+			expr:      "@weekly",
+			prevStart: time.Date(2021, 01, 01, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 01, 03, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should run every interval after the previous run": {
+			expr:      "@every 5m",
+			prevStart: time.Date(2021, 01, 01, 0, 1, 2, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 01, 01, 0, 6, 2, 0, time.UTC),
+				time.Date(2021, 01, 01, 0, 11, 2, 0, time.UTC),
+			},
+		},
+		"Should evaluate in the location of the previous run": {
+			expr:      "0 9 * * *",
+			prevStart: time.Date(2021, 03, 13, 12, 0, 0, 0, toronto),
+			want: []time.Time{
+				time.Date(2021, 03, 14, 9, 0, 0, 0, toronto),
+				time.Date(2021, 03, 15, 9, 0, 0, 0, toronto),
+			},
+		},
+		"Should evaluate in the location of the CRON_TZ prefix": {
+			expr:      "CRON_TZ=America/Toronto 0 9 * * *",
+			prevStart: time.Date(2021, 01, 01, 12, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 01, 01, 14, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should run a time skipped by the spring forward gap when the gap ends": {
+			expr:      "TZ=America/Toronto 30 2 * * *",
+			prevStart: time.Date(2021, 03, 13, 12, 0, 0, 0, toronto),
+			want: []time.Time{
+				time.Date(2021, 03, 14, 3, 0, 0, 0, toronto),
+				time.Date(2021, 03, 15, 2, 30, 0, 0, toronto),
+			},
+		},
+		"Should run descriptors in the location of the CRON_TZ prefix": {
+			expr:      "CRON_TZ=America/Toronto @daily",
+			prevStart: time.Date(2021, 01, 01, 12, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 01, 02, 5, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := Parse(tt.expr)
+			require.NoError(t, err)
+
+			prev := tt.prevStart
+			for _, want := range tt.want {
+				prev = s.Next(prev)
+				assert.True(t, want.Equal(prev), "want %s got %s", want.UTC(), prev.UTC())
+			}
+		})
+	}
+}
+
+func TestParseInLocation(t *testing.T) {
+	toronto, err := time.LoadLocation("America/Toronto")
+	require.NoError(t, err)
+
+	s, err := ParseInLocation("0 9 * * *", toronto)
+	require.NoError(t, err)
+	assert.Equal(t, toronto, s.Location())
+
+	s, err = ParseInLocation("CRON_TZ=UTC 0 9 * * *", toronto)
+	require.NoError(t, err)
+	assert.Equal(t, time.UTC, s.Location())
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := map[string]struct {
+		expr  string
+		field string
+		pos   int
+	}{
+		"Should reject an empty expression":               {expr: "  ", pos: 0},
+		"Should reject too few fields":                    {expr: "* * * *", pos: 7},
+		"Should reject too many fields":                   {expr: "0 * * * * * 2021", pos: 12},
+		"Should reject a value out of range":              {expr: "0 24 * * *", field: "hour", pos: 2},
+		"Should reject an invalid value in a list":        {expr: "1,2,x * * * *", field: "minute", pos: 4},
+		"Should reject an invalid end of a range":         {expr: "* * * 1-13 *", field: "month", pos: 8},
+		"Should reject a backwards range":                 {expr: "* * * * FRI-MON", field: "day-of-week", pos: 8},
+		"Should reject a zero step":                       {expr: "*/0 * * * * *", field: "second", pos: 2},
+		"Should reject a step larger than the field":      {expr: "* * */32 * *", field: "day-of-month", pos: 6},
+		"Should reject an empty list item":                {expr: "1,,2 * * * *", field: "minute", pos: 2},
+		"Should reject ? outside day fields":              {expr: "? * * * *", field: "minute", pos: 0},
+		"Should reject an unknown descriptor":             {expr: "@fortnightly", pos: 0},
+		"Should reject arguments after a descriptor":      {expr: "@daily 5", pos: 7},
+		"Should reject an invalid @every duration":        {expr: "@every 5 minutes", pos: 0},
+		"Should reject a negative @every duration":        {expr: "@every -5m", pos: 7},
+		"Should reject an unknown time zone":              {expr: "CRON_TZ=Mars/Olympus_Mons * * * * *", pos: 8},
+		"Should reject a time zone without an expression": {expr: "CRON_TZ=UTC", pos: 11},
+		"Should reject a hash without a range":            {expr: "H(5) * * * *", field: "minute", pos: 0},
+		"Should reject a hash range out of range":         {expr: "0 H(0-24) * * *", field: "hour", pos: 6},
+		"Should reject an invalid hash step":              {expr: "H(0-9)/20 * * * *", field: "minute", pos: 7},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Parse(tt.expr)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, ErrInvalidExpression))
+
+			var perr *ParseError
+			require.True(t, errors.As(err, &perr))
+			assert.Equal(t, tt.expr, perr.Expr)
+			assert.Equal(t, tt.field, perr.Field)
+			assert.Equal(t, tt.pos, perr.Pos, perr.Error())
+		})
+	}
+}
+
+type namedJob string
+
+func (n namedJob) Name() string {
+	return string(n)
+}
+
+func (namedJob) Runner() job.JobFn {
+	return func(ctx context.Context) error { return nil }
+}
+
+func TestSchedule_Bind(t *testing.T) {
+	prev := time.Date(2020, 12, 31, 23, 59, 59, 0, time.UTC)
+
+	t.Run("Should derive a stable value from the job name", func(t *testing.T) {
+		t.Parallel()
+
+		s, err := Parse("H * * * *")
+		require.NoError(t, err)
+
+		first := s.Bind(namedJob("report")).Next(prev)
+		assert.Equal(t, first, s.Bind(namedJob("report")).Next(prev))
+
+		again, err := Parse("H * * * *")
+		require.NoError(t, err)
+		assert.Equal(t, first, again.Bind(namedJob("report")).Next(prev))
+	})
+
+	t.Run("Should spread identical schedules across the field", func(t *testing.T) {
+		t.Parallel()
+
+		s, err := Parse("H * * * *")
+		require.NoError(t, err)
+
+		minutes := map[int]bool{}
+		for i := 0; i < 100; i++ {
+			next := s.Bind(namedJob(fmt.Sprintf("job-%d", i))).Next(prev)
+			require.Equal(t, 0, next.Hour())
+			minutes[next.Minute()] = true
+		}
+
+		assert.Greater(t, len(minutes), 30)
+	})
+
+	t.Run("Should keep hashed steps within the range", func(t *testing.T) {
+		t.Parallel()
+
+		s, err := Parse("H(0-29)/5 * * * *")
+		require.NoError(t, err)
+
+		for i := 0; i < 20; i++ {
+			timer := s.Bind(namedJob(fmt.Sprintf("job-%d", i)))
+
+			next := timer.Next(prev)
+			require.Less(t, next.Minute(), 5)
+
+			for run := 0; run < 12; run++ {
+				following := timer.Next(next)
+				if next.Hour() == following.Hour() {
+					require.Equal(t, 5*time.Minute, following.Sub(next))
+				}
+
+				require.Less(t, following.Minute(), 30)
+				next = following
+			}
+		}
+	})
+
+	t.Run("Should hash days of the month which occur every month", func(t *testing.T) {
+		t.Parallel()
+
+		s, err := Parse("0 0 H * *")
+		require.NoError(t, err)
+
+		for i := 0; i < 100; i++ {
+			next := s.Bind(namedJob(fmt.Sprintf("job-%d", i))).Next(prev)
+			require.LessOrEqual(t, next.Day(), 28)
+		}
+	})
+
+	t.Run("Should run without binding", func(t *testing.T) {
+		t.Parallel()
+
+		s, err := Parse("H H * * *")
+		require.NoError(t, err)
+		assert.False(t, s.Next(prev).IsZero())
+	})
+}
@@ -0,0 +1,404 @@
+package cron
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidExpression is wrapped by every ParseError
+var ErrInvalidExpression error = fmt.Errorf("invalid cron expression")
+
+// ParseError describes where and why an expression failed to parse
+type ParseError struct {
+	Expr string
+	// Field is the name of the field containing the error, empty when the error is not within a field
+	Field string
+	// Pos is the byte offset of the error in Expr
+	Pos int
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("cron: %q at position %d: %s", e.Expr, e.Pos, e.Msg)
+	}
+
+	return fmt.Sprintf("cron: %q at position %d in %s field: %s", e.Expr, e.Pos, e.Field, e.Msg)
+}
+
+func (e *ParseError) Unwrap() error {
+	return ErrInvalidExpression
+}
+
+type bounds struct {
+	name     string
+	min, max int
+	names    map[string]int
+	// hashMax is the upper bound of H, it excludes values which do not occur in every period of the field
+	hashMax int
+}
+
+var (
+	seconds = bounds{name: "second", min: 0, max: 59, hashMax: 59}
+	minutes = bounds{name: "minute", min: 0, max: 59, hashMax: 59}
+	hours   = bounds{name: "hour", min: 0, max: 23, hashMax: 23}
+	doms    = bounds{name: "day-of-month", min: 1, max: 31, hashMax: 28}
+	months  = bounds{name: "month", min: 1, max: 12, hashMax: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// dows allows 7 for Sunday, folded into 0 once the field is parsed
+	dows = bounds{name: "day-of-week", min: 0, max: 7, hashMax: 6, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+// Parse parses a cron expression, see the package documentation for the supported syntax
+// Errors are *ParseError
+func Parse(expr string) (*Schedule, error) {
+	return ParseInLocation(expr, nil)
+}
+
+// ParseInLocation parses a cron expression evaluated on the wall clock of loc unless it has a CRON_TZ prefix
+func ParseInLocation(expr string, loc *time.Location) (*Schedule, error) {
+	p := &parser{expr: expr}
+
+	s, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	if s.loc == nil {
+		s.loc = loc
+	}
+
+	return s, nil
+}
+
+type token struct {
+	text string
+	pos  int
+}
+
+type parser struct {
+	expr string
+	// key seeds the values of hashed fields
+	key string
+	// hashed records whether the expression has a hashed field
+	hashed bool
+}
+
+func (p *parser) errorf(field string, pos int, format string, args ...interface{}) error {
+	return &ParseError{Expr: p.expr, Field: field, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) parse() (*Schedule, error) {
+	tokens := tokenize(p.expr)
+	if len(tokens) == 0 {
+		return nil, p.errorf("", 0, "empty expression")
+	}
+
+	s := &Schedule{expr: p.expr}
+
+	if tz, ok := timeZone(tokens[0].text); ok {
+		loc, err := time.LoadLocation(tz)
+		if tz == "" || err != nil {
+			return nil, p.errorf("", tokens[0].pos+len(tokens[0].text)-len(tz), "unknown time zone %q", tz)
+		}
+
+		s.loc = loc
+		tokens = tokens[1:]
+
+		if len(tokens) == 0 {
+			return nil, p.errorf("", len(p.expr), "missing expression after time zone")
+		}
+	}
+
+	if strings.HasPrefix(tokens[0].text, "@") {
+		return p.descriptor(s, tokens)
+	}
+
+	var fields []bounds
+
+	switch len(tokens) {
+	case 5:
+		s.second = 1
+		fields = []bounds{minutes, hours, doms, months, dows}
+	case 6:
+		fields = []bounds{seconds, minutes, hours, doms, months, dows}
+	default:
+		pos := len(p.expr)
+		if len(tokens) > 6 {
+			pos = tokens[6].pos
+		}
+
+		return nil, p.errorf("", pos, "expected 5 or 6 fields, found %d", len(tokens))
+	}
+
+	dst := []*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow}
+	if len(fields) == 6 {
+		dst = append([]*uint64{&s.second}, dst...)
+	}
+
+	for i, f := range fields {
+		bits, err := p.field(tokens[i], f)
+		if err != nil {
+			return nil, err
+		}
+
+		*dst[i] = bits
+	}
+
+	s.hashed = p.hashed
+	s.domAny = isAny(tokens[len(tokens)-3].text)
+	s.dowAny = isAny(tokens[len(tokens)-1].text)
+
+	if has(s.dow, 7) {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+
+	return s, nil
+}
+
+func (p *parser) descriptor(s *Schedule, tokens []token) (*Schedule, error) {
+	name := strings.ToLower(tokens[0].text)
+
+	if name == "@every" {
+		if len(tokens) != 2 {
+			return nil, p.errorf("", tokens[0].pos, "@every expects a single duration")
+		}
+
+		d, err := time.ParseDuration(tokens[1].text)
+		if err != nil || d <= 0 {
+			return nil, p.errorf("", tokens[1].pos, "invalid duration %q", tokens[1].text)
+		}
+
+		s.every = d
+
+		return s, nil
+	}
+
+	spec, ok := descriptors[name]
+	if !ok {
+		return nil, p.errorf("", tokens[0].pos, "unknown descriptor %q", tokens[0].text)
+	}
+
+	if len(tokens) > 1 {
+		return nil, p.errorf("", tokens[1].pos, "unexpected %q after %s", tokens[1].text, tokens[0].text)
+	}
+
+	d, err := (&parser{expr: spec}).parse()
+	if err != nil {
+		return nil, err
+	}
+
+	d.expr = s.expr
+	d.loc = s.loc
+
+	return d, nil
+}
+
+// field parses a comma separated list of items into a bitset of the values they match
+func (p *parser) field(t token, b bounds) (uint64, error) {
+	var bits uint64
+
+	pos := t.pos
+	for _, item := range strings.Split(t.text, ",") {
+		v, err := p.item(item, pos, b)
+		if err != nil {
+			return 0, err
+		}
+
+		bits |= v
+		pos += len(item) + 1
+	}
+
+	return bits, nil
+}
+
+// item parses a single *, value, range, step or hashed value
+func (p *parser) item(item string, pos int, b bounds) (uint64, error) {
+	if item == "" {
+		return 0, p.errorf(b.name, pos, "empty list item")
+	}
+
+	rng, step, hasStep := item, "", false
+	if i := strings.IndexByte(item, '/'); i >= 0 {
+		rng, step, hasStep = item[:i], item[i+1:], true
+	}
+
+	lo, hi := b.min, b.max
+
+	switch {
+	case strings.HasPrefix(rng, "H"):
+		return p.hash(rng, step, hasStep, pos, b)
+	case isAny(rng):
+		if rng == "?" && b.name != doms.name && b.name != dows.name {
+			return 0, p.errorf(b.name, pos, "? is only allowed in day fields")
+		}
+	case strings.IndexByte(rng, '-') >= 0:
+		i := strings.IndexByte(rng, '-')
+
+		var err error
+		if lo, err = p.value(rng[:i], pos, b); err != nil {
+			return 0, err
+		}
+
+		if hi, err = p.value(rng[i+1:], pos+i+1, b); err != nil {
+			return 0, err
+		}
+
+		if lo > hi {
+			return 0, p.errorf(b.name, pos, "range %s is backwards", rng)
+		}
+	default:
+		v, err := p.value(rng, pos, b)
+		if err != nil {
+			return 0, err
+		}
+
+		// a value with a step runs from the value to the end of the field, e.g. 5/15 is 5-59/15
+		lo = v
+		if !hasStep {
+			hi = v
+		}
+	}
+
+	n := 1
+	if hasStep {
+		stepPos := pos + len(rng) + 1
+
+		var err error
+		if n, err = strconv.Atoi(step); err != nil || n <= 0 {
+			return 0, p.errorf(b.name, stepPos, "invalid step %q", step)
+		}
+
+		if n > b.max-b.min+1 {
+			return 0, p.errorf(b.name, stepPos, "step %d is larger than the range of the field", n)
+		}
+	}
+
+	var bits uint64
+	for v := lo; v <= hi; v += n {
+		bits |= 1 << uint(v)
+	}
+
+	return bits, nil
+}
+
+func (p *parser) value(s string, pos int, b bounds) (int, error) {
+	if v, ok := b.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, p.errorf(b.name, pos, "invalid value %q", s)
+	}
+
+	if v < b.min || v > b.max {
+		return 0, p.errorf(b.name, pos, "value %d out of range %d-%d", v, b.min, b.max)
+	}
+
+	return v, nil
+}
+
+// hash parses H, H/n, H(a-b) and H(a-b)/n
+// H picks a value in the range from a hash of the key, with a step the hash picks the offset of the first value instead
+func (p *parser) hash(rng, step string, hasStep bool, pos int, b bounds) (uint64, error) {
+	lo, hi := b.min, b.hashMax
+
+	if rng != "H" {
+		if !strings.HasPrefix(rng, "H(") || !strings.HasSuffix(rng, ")") || strings.IndexByte(rng, '-') < 0 {
+			return 0, p.errorf(b.name, pos, "invalid hash %q, expected H or H(a-b)", rng)
+		}
+
+		inner := rng[2 : len(rng)-1]
+		i := strings.IndexByte(inner, '-')
+
+		var err error
+		if lo, err = p.value(inner[:i], pos+2, b); err != nil {
+			return 0, err
+		}
+
+		if hi, err = p.value(inner[i+1:], pos+2+i+1, b); err != nil {
+			return 0, err
+		}
+
+		if lo > hi {
+			return 0, p.errorf(b.name, pos, "range %s is backwards", inner)
+		}
+	}
+
+	p.hashed = true
+
+	h := fnv.New64a()
+	h.Write([]byte(p.key))
+	h.Write([]byte{0})
+	h.Write([]byte(b.name))
+	sum := h.Sum64()
+
+	if !hasStep {
+		return 1 << uint(lo+int(sum%uint64(hi-lo+1))), nil
+	}
+
+	n, err := strconv.Atoi(step)
+	if err != nil || n <= 0 || n > hi-lo+1 {
+		return 0, p.errorf(b.name, pos+len(rng)+1, "invalid step %q", step)
+	}
+
+	var bits uint64
+	for v := lo + int(sum%uint64(n)); v <= hi; v += n {
+		bits |= 1 << uint(v)
+	}
+
+	return bits, nil
+}
+
+func isAny(s string) bool {
+	return s == "*" || s == "?"
+}
+
+// timeZone returns the zone of a CRON_TZ= or TZ= prefix
+func timeZone(s string) (string, bool) {
+	for _, prefix := range []string{"CRON_TZ=", "TZ="} {
+		if strings.HasPrefix(s, prefix) {
+			return s[len(prefix):], true
+		}
+	}
+
+	return "", false
+}
+
+// tokenize splits expr on whitespace recording the offset of each token
+func tokenize(expr string) []token {
+	var tokens []token
+
+	start := -1
+	for i, r := range expr + " " {
+		switch {
+		case r == ' ' || r == '\t' || r == '\n':
+			if start >= 0 {
+				tokens = append(tokens, token{text: expr[start:i], pos: start})
+				start = -1
+			}
+		case start < 0:
+			start = i
+		}
+	}
+
+	return tokens
+}
@@ -2,8 +2,12 @@ package cronalt
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ahmedalhulaibi/cronalt/job"
@@ -22,16 +26,59 @@ type waitGroup interface {
 }
 
 type Scheduler struct {
-	// jobPool is a Semaphore to limit the number of concurrent jobs running at once
-	jobPool chan empty
+	// jobPool is a Semaphore to limit the number of concurrent jobs running at once, granting slots by priority
+	jobPool *jobPool
 	jobs    jobStore
 	wg      waitGroup
 	log     logger
 	clock   clock
+
+	// groups are Semaphores limiting the concurrent runs of the jobs in each named concurrency group
+	groups map[string]chan empty
+	// freed is signalled whenever slots of the job pool or of groups are released, see tryAcquire
+	freed chan empty
+
+	// heapDispatch selects the single heap based dispatcher instead of one loop per job
+	heapDispatch bool
+
+	statuses  *statusBoard
+	listeners []Listener
+	newRunID  func(j job.Job) string
+	// runSeq is incremented atomically to generate the default run IDs
+	runSeq uint64
+
+	// runOnStart is the default of WithRunOnStart for jobs which do not set it
+	runOnStart bool
+	// startupStagger spaces the runs on start of jobs apart
+	startupStagger time.Duration
+	// timeout and abandonAfter are the defaults of WithTimeout and WithAbandonAfter for jobs which do not set them
+	timeout      time.Duration
+	abandonAfter time.Duration
+
+	// mu guards the fields below which are only set while the Scheduler is started
+	mu sync.Mutex
+	// ctx is the context passed to Start which jobs run with, it is nil when the Scheduler is not started
+	ctx context.Context
+	// stop cancels the parent context of all job loops to halt queuing new runs
+	stop context.CancelFunc
+	// dispatcher drives the scheduled runs of started jobs
+	dispatcher dispatcher
+	// paused holds the names of paused jobs
+	paused map[string]bool
+	// lastStartSlot is the time of the latest run on start, see startSlot
+	lastStartSlot time.Time
 }
 
 var (
 	ErrMaxConcurrentJobsZero error = fmt.Errorf("maxConcurrentJobs must be greater than zero")
+	ErrTooManyReservedSlots  error = fmt.Errorf("reserved slots exceed maxConcurrentJobs")
+	ErrGroupLimitZero        error = fmt.Errorf("concurrency group limit must be greater than zero")
+	ErrUnknownGroup          error = fmt.Errorf("unknown concurrency group")
+	ErrShutdownTimeout       error = fmt.Errorf("shutdown deadline exceeded with jobs still running")
+	ErrPoolSaturated         error = fmt.Errorf("pool saturated")
+	ErrJobPanicked           error = fmt.Errorf("job panicked")
+	ErrJobTimedOut           error = fmt.Errorf("job timed out")
+	ErrJobAbandoned          error = fmt.Errorf("job abandoned")
 )
 
 func NewScheduler(maxConcurrentJobs int, opts ...SchedulerOption) (*Scheduler, error) {
@@ -42,20 +89,41 @@ func NewScheduler(maxConcurrentJobs int, opts ...SchedulerOption) (*Scheduler, e
 	var wg sync.WaitGroup
 
 	s := &Scheduler{
-		jobPool: make(chan empty, maxConcurrentJobs),
+		jobPool: newJobPool(maxConcurrentJobs),
+		groups:  make(map[string]chan empty),
+		freed:   make(chan empty, 1),
 		jobs:    job.NewStore(),
 		log:     noopLogger{},
 		clock:   timeProvider{},
 		wg:      &wg,
+
+		statuses: newStatusBoard(),
+
+		paused: make(map[string]bool),
 	}
 
 	for _, opt := range opts {
 		s = opt(s)
 	}
 
+	if s.jobPool.reservedSlots() > maxConcurrentJobs {
+		return nil, ErrTooManyReservedSlots
+	}
+
+	for name, group := range s.groups {
+		if cap(group) <= 0 {
+			return nil, fmt.Errorf("%w:%s", ErrGroupLimitZero, name)
+		}
+	}
+
 	return s, nil
 }
 
+// validator is implemented by timers built in steps which record the first error, e.g. DayTimer
+type validator interface {
+	Err() error
+}
+
 type SchedulerOption func(s *Scheduler) *Scheduler
 
 // WithLogger returns a SchedulerOption to inject a logger
@@ -83,6 +151,102 @@ func WithWaitGroup(wg waitGroup) SchedulerOption {
 	}
 }
 
+// WithListener returns a SchedulerOption to register a Listener for job lifecycle events
+// It can be passed multiple times to register multiple listeners
+func WithListener(l Listener) SchedulerOption {
+	return func(s *Scheduler) *Scheduler {
+		s.listeners = append(s.listeners, l)
+		return s
+	}
+}
+
+// WithRunIDGenerator returns a SchedulerOption to generate the run IDs passed to listeners
+// Default is the job name followed by a sequence number
+func WithRunIDGenerator(gen func(j job.Job) string) SchedulerOption {
+	return func(s *Scheduler) *Scheduler {
+		s.newRunID = gen
+		return s
+	}
+}
+
+// WithHeapDispatcher returns a SchedulerOption to drive all jobs from a single goroutine and timer
+// Next runs are kept in a min-heap and due runs are handed to a fixed set of maxConcurrentJobs worker goroutines
+// once they hold their slots, so runs waiting for a slot or a concurrency group never hold up a worker
+// This avoids an idle goroutine and timer per job, which suits tens of thousands of jobs
+func WithHeapDispatcher() SchedulerOption {
+	return func(s *Scheduler) *Scheduler {
+		s.heapDispatch = true
+		return s
+	}
+}
+
+// WithRunOnStartDefault returns a SchedulerOption to run every job once as soon as it starts, see WithRunOnStart
+// A job can opt out with WithRunOnStart(false)
+func WithRunOnStartDefault() SchedulerOption {
+	return func(s *Scheduler) *Scheduler {
+		s.runOnStart = true
+		return s
+	}
+}
+
+// WithStartupStagger returns a SchedulerOption to space the runs on start of jobs d apart in the order the jobs start
+// so they do not all hit the job pool at the same instant, default is zero which runs them all immediately
+func WithStartupStagger(d time.Duration) SchedulerOption {
+	return func(s *Scheduler) *Scheduler {
+		s.startupStagger = d
+		return s
+	}
+}
+
+// WithDefaultTimeout returns a SchedulerOption to bound how long each run of a job takes, see WithTimeout
+// A job can override it with WithTimeout, default is zero which does not bound runs
+func WithDefaultTimeout(d time.Duration) SchedulerOption {
+	return func(s *Scheduler) *Scheduler {
+		s.timeout = d
+		return s
+	}
+}
+
+// WithDefaultAbandonAfter returns a SchedulerOption to abandon runs which outlive their timeout by grace, see WithAbandonAfter
+// A job can override it with WithAbandonAfter
+func WithDefaultAbandonAfter(grace time.Duration) SchedulerOption {
+	return func(s *Scheduler) *Scheduler {
+		s.abandonAfter = grace
+		return s
+	}
+}
+
+// WithReservedSlots returns a SchedulerOption to keep n slots of the job pool for jobs of class p, see WithPriority
+// Other classes are not granted those slots even when they are free, reserved slots may not exceed maxConcurrentJobs
+func WithReservedSlots(p Priority, n int) SchedulerOption {
+	return func(s *Scheduler) *Scheduler {
+		s.jobPool.reserved[p] = n
+		return s
+	}
+}
+
+// WithPriorityAging returns a SchedulerOption to raise the priority of a run waiting for a slot by one class for every d it waited
+// so runs of lower classes are not starved by a steady stream of higher ones, default is zero which disables aging
+func WithPriorityAging(d time.Duration) SchedulerOption {
+	return func(s *Scheduler) *Scheduler {
+		s.jobPool.aging = d
+		return s
+	}
+}
+
+// WithConcurrencyGroup returns a SchedulerOption to register a named concurrency group running at most limit jobs at once
+// e.g. the jobs sharing a database, jobs join groups with WithGroups and the limit applies on top of maxConcurrentJobs
+func WithConcurrencyGroup(name string, limit int) SchedulerOption {
+	return func(s *Scheduler) *Scheduler {
+		if limit < 0 {
+			limit = 0
+		}
+
+		s.groups[name] = make(chan empty, limit)
+		return s
+	}
+}
+
 // WithJobStore returns a SchedulerOption to inject a jobStore, default is job.store
 func WithJobStore(js jobStore) SchedulerOption {
 	return func(s *Scheduler) *Scheduler {
@@ -92,72 +256,647 @@ func WithJobStore(js jobStore) SchedulerOption {
 }
 
 // Schedule registers a job and uses job function name as the job name
-func (s *Scheduler) Schedule(jt job.Timer, j job.Job) error {
-	return s.jobs.Add(jobCfg{timer: jt, j: j})
+// If the Scheduler is already started the job is started right away without affecting other jobs
+func (s *Scheduler) Schedule(jt job.Timer, j job.Job, opts ...JobOption) error {
+	if v, ok := jt.(validator); ok {
+		if err := v.Err(); err != nil {
+			return err
+		}
+	}
+
+	if b, ok := jt.(job.Binder); ok {
+		jt = b.Bind(j)
+	}
+
+	cfg := jobCfg{timer: jt, j: j}
+
+	for _, opt := range opts {
+		cfg = opt(cfg)
+	}
+
+	for _, group := range cfg.groups {
+		if _, ok := s.groups[group]; !ok {
+			return fmt.Errorf("%w:%s", ErrUnknownGroup, group)
+		}
+	}
+
+	return s.add(cfg)
+}
+
+// add registers a job, reports it as scheduled and starts its loop if the Scheduler is started
+// OnScheduled is sent before the loop starts so it comes before any other event of the job
+func (s *Scheduler) add(cfg jobCfg) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.jobs.Add(cfg); err != nil {
+		return err
+	}
+
+	s.statuses.reset(cfg.Job().Name())
+
+	ctx := s.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	s.notify(func(l Listener) {
+		l.OnScheduled(ctx, Event{Job: cfg.Job().Name(), Time: s.clock.Now()})
+	})
+
+	if s.ctx != nil {
+		s.dispatcher.start(cfg)
+	}
+
+	return nil
+}
+
+// Remove unregisters a job by name
+// If the Scheduler is started only the loop of the removed job is stopped, a run in progress is left to finish
+// The status of the job is dropped once it has no queued or running runs left
+func (s *Scheduler) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.jobs.Remove(name); err != nil {
+		return err
+	}
+
+	if s.dispatcher != nil {
+		s.dispatcher.remove(name)
+	}
+
+	delete(s.paused, name)
+	s.statuses.remove(name)
+
+	return nil
+}
+
+// Pause suspends the scheduled runs of a job while keeping it registered
+// Runs which become due while the job is paused are missed, see Resume
+func (s *Scheduler) Pause(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.jobs.Get(name); err != nil {
+		return err
+	}
+
+	s.paused[name] = true
+
+	return nil
+}
+
+// Resume resumes the scheduled runs of a paused job
+// By default the job continues from its next future run, see ResumeCatchUp
+func (s *Scheduler) Resume(name string, opts ...ResumeOption) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.jobs.Get(name); err != nil {
+		return err
+	}
+
+	var o resumeOptions
+	for _, opt := range opts {
+		o = opt(o)
+	}
+
+	delete(s.paused, name)
+
+	if s.dispatcher != nil {
+		s.dispatcher.resume(name, o.catchUp)
+	}
+
+	return nil
+}
+
+func (s *Scheduler) isPaused(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.paused[name]
+}
+
+type resumeOptions struct {
+	catchUp bool
+}
+
+// ResumeOption configures a single call to Scheduler.Resume
+type ResumeOption func(o resumeOptions) resumeOptions
+
+// ResumeCatchUp returns a ResumeOption to run the job immediately if a run was missed while it was paused
+func ResumeCatchUp() ResumeOption {
+	return func(o resumeOptions) resumeOptions {
+		o.catchUp = true
+		return o
+	}
 }
 
 // Start starts all the scheduled jobs in their own go routine and blocks indefinitely or until context is cancelled
+// Jobs can be scheduled and removed while the Scheduler is started
 func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	s.ctx = ctx
+
+	loopCtx, stop := context.WithCancel(ctx)
+	s.stop = stop
+
+	if s.heapDispatch {
+		s.dispatcher = newHeapDispatcher(s, ctx, loopCtx)
+	} else {
+		s.dispatcher = newLoopDispatcher(s, ctx, loopCtx)
+	}
+
 	for _, pendingJob := range s.jobs.GetAll() {
-		s.wg.Add(1)
-		s.log.Info(ctx, "cronalt.Scheduler starting job", KeyVal{"job", pendingJob.Job().Name()})
-		go s.run(ctx, pendingJob)
+		s.dispatcher.start(configOf(pendingJob))
 	}
+	s.mu.Unlock()
+
+	<-loopCtx.Done()
+
+	s.halt()
 	s.wg.Wait()
 }
 
-func (s *Scheduler) run(ctx context.Context, runJobCfg job.Config) {
-	defer s.wg.Done()
+// Shutdown stops all job loops from queuing new runs and waits for in-flight runs to finish
+// Runs in progress keep the context passed to Start, Shutdown does not cancel it
+// If ctx is done before all runs finish, an error wrapping ErrShutdownTimeout listing the jobs still running is returned
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.halt()
+
+	done := make(chan empty)
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w:%s", ErrShutdownTimeout, strings.Join(s.statuses.inflight(), ","))
+	}
+}
+
+// halt stops all job loops and marks the Scheduler as not started
+func (s *Scheduler) halt() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	jobName := runJobCfg.Job().Name()
+	if s.stop != nil {
+		s.stop()
+	}
+
+	s.ctx = nil
+	s.stop = nil
+	s.dispatcher = nil
+}
 
-	timer := time.NewTimer(s.getTimeUntilNextRun(ctx, s.clock.Now(), runJobCfg))
+// runOnce queues a single run of a job on the job pool, runs it and returns its error
+// The run is dropped and reported as skipped if queueCtx is done or the job's maximum queue wait elapses before a slot is free
+func (s *Scheduler) runOnce(ctx, queueCtx context.Context, cfg jobCfg) error {
+	runID := s.queue(ctx, cfg)
 
-	for {
+	return s.runQueued(ctx, queueCtx, cfg, runID, cfg.maxQueueWait)
+}
+
+// queue reports a new run of a job as queued and returns its run ID
+func (s *Scheduler) queue(ctx context.Context, cfg jobCfg) string {
+	jobName := cfg.Job().Name()
+	runID := s.runID(cfg.Job())
+
+	s.statuses.update(jobName, func(rs *runStatus) { rs.queued++ })
+
+	s.log.Info(ctx, "cronalt.Scheduler queued", KeyVal{"job", jobName})
+	s.notify(func(l Listener) {
+		l.OnQueued(ctx, Event{Job: jobName, RunID: runID, Time: s.clock.Now()})
+	})
+
+	return runID
+}
+
+// dropQueued reports a queued run which is dropped before it starts because of err
+func (s *Scheduler) dropQueued(ctx context.Context, jobName, runID string, err error) {
+	s.statuses.update(jobName, func(rs *runStatus) { rs.queued-- })
+	s.skipped(ctx, jobName, runID, err.Error())
+}
+
+// runQueued waits up to maxWait for a slot in the job pool for a queued run, runs it and returns its error
+func (s *Scheduler) runQueued(ctx, queueCtx context.Context, cfg jobCfg, runID string, maxWait time.Duration) error {
+	// Acquire lock on job pool semaphore
+	if err := s.acquire(queueCtx, maxWait, cfg.priority, cfg.groups...); err != nil {
+		s.dropQueued(ctx, cfg.Job().Name(), runID, err)
+		return err
+	}
+
+	return s.runAcquired(ctx, cfg, runID)
+}
+
+// runAcquired runs a queued run which holds its slots, returns its error and releases the slots
+func (s *Scheduler) runAcquired(ctx context.Context, cfg jobCfg, runID string) error {
+	jobName := cfg.Job().Name()
+
+	s.log.Info(ctx, "cronalt.Scheduler running", KeyVal{"job", jobName})
+
+	started := s.clock.Now()
+	s.statuses.update(jobName, func(rs *runStatus) {
+		rs.queued--
+		rs.running++
+		rs.LastStart = started
+	})
+
+	s.notify(func(l Listener) {
+		l.OnStarted(ctx, Event{Job: jobName, RunID: runID, Time: started, StartedAt: started})
+	})
+
+	err := s.execute(ctx, cfg)
+
+	ended := s.clock.Now()
+	e := Event{Job: jobName, RunID: runID, Time: ended, StartedAt: started, Duration: ended.Sub(started), Err: err}
+
+	s.statuses.update(jobName, func(rs *runStatus) {
+		rs.running--
+		rs.LastEnd = ended
+		rs.LastDuration = e.Duration
+		rs.LastErr = err
+
+		if err != nil {
+			rs.ConsecutiveFailures++
+		} else {
+			rs.ConsecutiveFailures = 0
+		}
+	})
+
+	switch {
+	case errors.Is(err, ErrJobPanicked):
+		// The panic is already logged by recoverJob
+		s.notify(func(l Listener) { l.OnPanic(ctx, e) })
+	case errors.Is(err, ErrJobTimedOut) || errors.Is(err, ErrJobAbandoned):
+		s.log.Error(
+			ctx,
+			"cronalt.Scheduler job timed out",
+			KeyVal{"job", jobName},
+			KeyVal{"error", err.Error()},
+		)
+		s.notify(func(l Listener) { l.OnTimedOut(ctx, e) })
+	case err != nil:
+		s.log.Error(
+			ctx,
+			"cronalt.Scheduler job completed with error",
+			KeyVal{"job", jobName},
+			KeyVal{"error", err.Error()},
+		)
+		s.notify(func(l Listener) { l.OnFailed(ctx, e) })
+	default:
+		s.notify(func(l Listener) { l.OnCompleted(ctx, e) })
+	}
+
+	s.log.Info(ctx, "cronalt.Scheduler completed", KeyVal{"job", jobName})
+
+	// Release lock on job pool semaphore
+	s.release(cfg)
+
+	return err
+}
+
+// execute calls the job of cfg with a context bounded by its timeout and returns its error
+// A run which outlives its timeout returns an error wrapping ErrJobTimedOut, unless it panicked
+// A run which outlives its timeout by the abandon grace period is abandoned and an error wrapping ErrJobAbandoned is returned,
+// it keeps running in the background without holding its slot in the job pool and Shutdown does not wait for it
+func (s *Scheduler) execute(ctx context.Context, cfg jobCfg) error {
+	timeout, grace := cfg.timeout, cfg.abandonAfter
+	if timeout == 0 {
+		timeout = s.timeout
+	}
+
+	if grace == 0 {
+		grace = s.abandonAfter
+	}
+
+	if timeout <= 0 {
+		return call(ctx, cfg.Job(), s.log)
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+
+	if grace <= 0 {
+		defer cancel()
+		return timedOut(ctx, runCtx, call(runCtx, cfg.Job(), s.log), timeout)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		defer cancel()
+		done <- call(runCtx, cfg.Job(), s.log)
+	}()
+
+	abandon := time.NewTimer(timeout + grace)
+	defer abandon.Stop()
+
+	select {
+	case err := <-done:
+		return timedOut(ctx, runCtx, err, timeout)
+	case <-abandon.C:
+		s.log.Warn(ctx, "cronalt.Scheduler abandoned", KeyVal{"job", cfg.Job().Name()})
+		return fmt.Errorf("%w:still running %s after its timeout of %s", ErrJobAbandoned, grace, timeout)
+	}
+}
+
+// timedOut returns err wrapped in ErrJobTimedOut when runCtx, derived from ctx, reached the timeout of the run
+func timedOut(ctx, runCtx context.Context, err error, timeout time.Duration) error {
+	if ctx.Err() != nil || !errors.Is(runCtx.Err(), context.DeadlineExceeded) || errors.Is(err, ErrJobPanicked) {
+		return err
+	}
+
+	if err == nil {
+		return fmt.Errorf("%w:exceeded %s", ErrJobTimedOut, timeout)
+	}
+
+	return fmt.Errorf("%w:exceeded %s:%s", ErrJobTimedOut, timeout, err)
+}
+
+// skipped reports a run which was dropped
+func (s *Scheduler) skipped(ctx context.Context, jobName, runID, reason string) {
+	s.log.Warn(
+		ctx,
+		"cronalt.Scheduler skipped",
+		KeyVal{"job", jobName},
+		KeyVal{"reason", reason},
+	)
+	s.notify(func(l Listener) {
+		l.OnSkipped(ctx, Event{Job: jobName, RunID: runID, Time: s.clock.Now(), Reason: reason})
+	})
+}
+
+// runID returns the identifier of a new run of j
+func (s *Scheduler) runID(j job.Job) string {
+	if s.newRunID != nil {
+		return s.newRunID(j)
+	}
+
+	return j.Name() + "-" + strconv.FormatUint(atomic.AddUint64(&s.runSeq, 1), 10)
+}
+
+// Trigger runs the named job once out-of-band through the job pool like a scheduled run
+// The run waits for a slot in the job pool with ctx, a run dropped because ctx is done is reported to Listener.OnSkipped
+// By default Trigger returns once the run is started in the background, see TriggerWait and TriggerResetTimer
+// A background run of a started Scheduler runs with the context passed to Start so it outlives ctx,
+// otherwise the run uses ctx as the context of the job as well
+// Shutdown waits for the runs triggered while the Scheduler is started
+func (s *Scheduler) Trigger(ctx context.Context, name string, opts ...TriggerOption) error {
+	c, err := s.jobs.Get(name)
+	if err != nil {
+		return err
+	}
+
+	cfg := configOf(c)
+
+	var o triggerOptions
+	for _, opt := range opts {
+		o = opt(o)
+	}
+
+	s.log.Info(ctx, "cronalt.Scheduler triggered", KeyVal{"job", name})
+
+	s.mu.Lock()
+	if s.dispatcher != nil && o.resetTimer {
+		s.dispatcher.reset(name, s.clock.Now())
+	}
+
+	runCtx := ctx
+
+	started := s.ctx != nil
+	if started {
+		s.wg.Add(1)
+
+		if !o.wait {
+			runCtx = s.ctx
+		}
+	}
+	s.mu.Unlock()
+
+	run := func() error {
+		if started {
+			defer s.wg.Done()
+		}
+
+		return s.runOnce(runCtx, ctx, cfg)
+	}
+
+	if o.wait {
+		return run()
+	}
+
+	go func() {
+		_ = run()
+	}()
+
+	return nil
+}
+
+type triggerOptions struct {
+	wait       bool
+	resetTimer bool
+}
+
+// TriggerOption configures a single call to Scheduler.Trigger
+type TriggerOption func(o triggerOptions) triggerOptions
+
+// TriggerWait returns a TriggerOption to block until the run completes and return its error
+func TriggerWait() TriggerOption {
+	return func(o triggerOptions) triggerOptions {
+		o.wait = true
+		return o
+	}
+}
+
+// TriggerResetTimer returns a TriggerOption to reschedule the job's regular runs from the time it was triggered
+// By default the job's regular timer is preserved
+func TriggerResetTimer() TriggerOption {
+	return func(o triggerOptions) triggerOptions {
+		o.resetTimer = true
+		return o
+	}
+}
+
+// acquire blocks until a slot in each of groups and then the job pool is granted to class priority, ctx is done or maxWait elapses
+// Groups are acquired before the job pool so a run waiting on a busy group does not hold a slot other jobs could use
+// A maxWait of zero waits indefinitely
+func (s *Scheduler) acquire(ctx context.Context, maxWait time.Duration, priority Priority, groups ...string) error {
+	var timeout <-chan time.Time
+
+	if maxWait > 0 {
+		t := time.NewTimer(maxWait)
+		defer t.Stop()
+
+		timeout = t.C
+	}
+
+	for i, group := range groups {
 		select {
+		case s.groups[group] <- empty{}:
+			continue
 		case <-ctx.Done():
-			s.log.Info(ctx, "cronalt.Scheduler halted", KeyVal{"job", jobName})
-			return
-		case now := <-timer.C:
-			s.log.Info(ctx, "cronalt.Scheduler queued", KeyVal{"job", jobName})
+			s.releaseGroups(groups[:i])
+			return ctx.Err()
+		case <-timeout:
+			s.releaseGroups(groups[:i])
+			return fmt.Errorf("%w:group %s", ErrPoolSaturated, group)
+		}
+	}
 
-			// Acquire lock on job pool semaphore
-			s.jobPool <- empty{}
+	w := s.jobPool.wait(priority, s.clock.Now())
 
-			s.log.Info(ctx, "cronalt.Scheduler running", KeyVal{"job", jobName})
+	var err error
 
-			if err := call(ctx, runJobCfg.Job(), s.log); err != nil {
-				s.log.Error(
-					ctx,
-					"cronalt.Scheduler job completed with error",
-					KeyVal{"job", jobName},
-					KeyVal{"error", err.Error()},
-				)
-			}
+	select {
+	case <-w.ready:
+		// select picks at random when ctx is done as well, the run must not start then
+		if err = ctx.Err(); err == nil {
+			return nil
+		}
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timeout:
+		err = ErrPoolSaturated
+	}
+
+	if !s.jobPool.cancel(w) {
+		// The slot was granted while giving up
+		s.jobPool.release(priority, s.clock.Now())
+	}
 
-			s.log.Info(ctx, "cronalt.Scheduler completed", KeyVal{"job", jobName})
+	s.releaseGroups(groups)
+	s.slotsFreed()
 
-			// Release lock on job pool semaphore
-			<-s.jobPool
+	return err
+}
 
-			// Use timer.Reset since we know the timer is expired and we can reset it
-			timer.Reset(s.getTimeUntilNextRun(ctx, now, runJobCfg))
+// tryAcquire takes a slot in each of groups and then the job pool for class priority without waiting
+// It returns false, holding no slot, when one of them is not free
+func (s *Scheduler) tryAcquire(priority Priority, groups []string) bool {
+	for i, group := range groups {
+		select {
+		case s.groups[group] <- empty{}:
+		default:
+			s.releaseGroups(groups[:i])
+			return false
 		}
 	}
+
+	if !s.jobPool.tryAcquire(priority) {
+		s.releaseGroups(groups)
+		return false
+	}
+
+	return true
+}
+
+// release frees the slots held by a run of cfg
+func (s *Scheduler) release(cfg jobCfg) {
+	s.jobPool.release(cfg.priority, s.clock.Now())
+	s.releaseGroups(cfg.groups)
+	s.slotsFreed()
+}
+
+// releaseGroups frees a slot in each of groups
+func (s *Scheduler) releaseGroups(groups []string) {
+	for _, group := range groups {
+		<-s.groups[group]
+	}
+}
+
+// slotsFreed signals freed without blocking
+func (s *Scheduler) slotsFreed() {
+	select {
+	case s.freed <- empty{}:
+	default:
+	}
 }
 
-func (s *Scheduler) getTimeUntilNextRun(ctx context.Context, prevTime time.Time, runJobCfg job.Config) time.Duration {
+// getTimeUntilNextRun returns the scheduled time of the run following prevTime and how long to wait for it
+// The time is zero when the job's timer has no further runs, the job is then reported as finished
+// A scheduled time which already passed is resolved by the job's MisfirePolicy
+func (s *Scheduler) getTimeUntilNextRun(ctx context.Context, prevTime time.Time, runJobCfg jobCfg) (time.Time, time.Duration) {
 	now := s.clock.Now()
 	nextExpectedRun := runJobCfg.Timer().Next(prevTime)
 
+	if !nextExpectedRun.IsZero() && nextExpectedRun.Before(now) {
+		s.log.Warn(
+			ctx,
+			"cronalt.Scheduler misfired",
+			KeyVal{"job", runJobCfg.Job().Name()},
+			KeyVal{"missed_run", nextExpectedRun.Format(time.RFC3339)},
+		)
+
+		nextExpectedRun = runJobCfg.misfirePolicy()(nextExpectedRun, now, runJobCfg.Timer())
+	}
+
+	if nextExpectedRun.IsZero() {
+		s.finished(ctx, runJobCfg.Job().Name())
+		return time.Time{}, 0
+	}
+
+	s.nextRunComputed(ctx, runJobCfg.Job().Name(), nextExpectedRun, now)
+
+	return nextExpectedRun, timeUntilNextRun(nextExpectedRun, now)
+}
+
+// firstRun returns the scheduled time of the first run of a job and how long to wait for it
+// at is the time of its run on start, when it is zero the first run is computed by the job's timer
+func (s *Scheduler) firstRun(ctx context.Context, cfg jobCfg, at time.Time) (time.Time, time.Duration) {
+	if at.IsZero() {
+		return s.getTimeUntilNextRun(ctx, s.firstPrev(cfg), cfg)
+	}
+
+	now := s.clock.Now()
+	s.nextRunComputed(ctx, cfg.Job().Name(), at, now)
+
+	return at, timeUntilNextRun(at, now)
+}
+
+// startSlot returns the time of the run on start of a job which is starting, zero when it does not run on start
+// Runs on start are spaced apart by the startup stagger, it is called with s.mu held
+func (s *Scheduler) startSlot(cfg jobCfg) time.Time {
+	if !cfg.runsOnStart(s.runOnStart) {
+		return time.Time{}
+	}
+
+	slot := s.clock.Now()
+	if next := s.lastStartSlot.Add(s.startupStagger); s.startupStagger > 0 && next.After(slot) {
+		slot = next
+	}
+
+	s.lastStartSlot = slot
+
+	return slot
+}
+
+// nextRunComputed reports the scheduled time of the next run of a job
+func (s *Scheduler) nextRunComputed(ctx context.Context, jobName string, next, now time.Time) {
 	s.log.Info(
 		ctx,
 		"cronalt.Scheduler next run",
-		KeyVal{"job", runJobCfg.Job().Name()},
-		KeyVal{"next_run", nextExpectedRun.Format(time.RFC3339)},
+		KeyVal{"job", jobName},
+		KeyVal{"next_run", next.Format(time.RFC3339)},
 	)
+	s.statuses.update(jobName, func(rs *runStatus) { rs.NextRun = next })
+	s.notify(func(l Listener) {
+		l.OnNextRunComputed(ctx, Event{Job: jobName, Time: now, NextRun: next})
+	})
+}
 
-	return timeUntilNextRun(nextExpectedRun, now)
+// finished reports a job whose timer has no further runs
+func (s *Scheduler) finished(ctx context.Context, jobName string) {
+	s.log.Info(ctx, "cronalt.Scheduler finished", KeyVal{"job", jobName})
+	s.statuses.update(jobName, func(rs *runStatus) {
+		rs.finished = true
+		rs.NextRun = time.Time{}
+	})
+	s.notify(func(l Listener) {
+		l.OnFinished(ctx, Event{Job: jobName, Time: s.clock.Now()})
+	})
 }
 
 func timeUntilNextRun(nextExpectedRun, now time.Time) time.Duration {
@@ -168,14 +907,19 @@ func timeUntilNextRun(nextExpectedRun, now time.Time) time.Duration {
 	return 0
 }
 
-func call(ctx context.Context, job job.Job, log logger) error {
-	defer recoverJob(ctx, job, log)
+func call(ctx context.Context, job job.Job, log logger) (err error) {
+	defer recoverJob(ctx, job, log, &err)
 
 	return job.Runner()(ctx)
 }
 
-func recoverJob(ctx context.Context, job job.Job, log logger) {
+// recoverJob logs a recovered panic and sets errp, if not nil, to an error wrapping ErrJobPanicked
+func recoverJob(ctx context.Context, job job.Job, log logger, errp *error) {
 	if r := recover(); r != nil {
+		if errp != nil {
+			*errp = fmt.Errorf("%w:%v", ErrJobPanicked, r)
+		}
+
 		keys := make([]KeyVal, 1, 2)
 		keys[0] = KeyVal{"job", job.Name()}
 
@@ -10,9 +10,12 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/ahmedalhulaibi/cronalt/cron"
 	"github.com/ahmedalhulaibi/cronalt/job"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -161,7 +164,7 @@ func Test_recoverJob(t *testing.T) {
 			}
 
 			func() {
-				defer recoverJob(tt.args.ctx, tt.args.job, tt.args.log)
+				defer recoverJob(tt.args.ctx, tt.args.job, tt.args.log, nil)
 
 				if tt.expectErr {
 					panic(fmt.Errorf("error message"))
@@ -332,6 +335,23 @@ func TestScheduler_Schedule(t *testing.T) {
 			require.EqualError(t, err, fmt.Errorf("%w:%s", job.ErrJobExists, "jobname").Error())
 		}
 	})
+
+	t.Run("Should bind a job.Binder timer to the job", func(t *testing.T) {
+		s, err := NewScheduler(10)
+		require.NoError(t, err)
+
+		hourly, err := cron.Parse("H * * * *")
+		require.NoError(t, err)
+
+		require.NoError(t, s.Schedule(hourly, signalJob{name: "spread"}))
+
+		cfg, err := s.jobs.Get("spread")
+		require.NoError(t, err)
+
+		prev := time.Date(2021, 01, 01, 0, 0, 0, 0, time.UTC)
+		assert.NotSame(t, hourly, cfg.Timer())
+		assert.Equal(t, hourly.Bind(signalJob{name: "spread"}).Next(prev), cfg.Timer().Next(prev))
+	})
 }
 
 func TestNewScheduler(t *testing.T) {
@@ -364,3 +384,971 @@ func TestNewScheduler(t *testing.T) {
 		})
 	}
 }
+
+type signalJob struct {
+	name string
+	ran  chan string
+}
+
+func (sj signalJob) Name() string {
+	return sj.name
+}
+
+func (sj signalJob) Runner() JobFn {
+	return func(ctx context.Context) error {
+		select {
+		case sj.ran <- sj.name:
+		case <-ctx.Done():
+		}
+		return nil
+	}
+}
+
+func TestScheduler_ScheduleWhileStarted(t *testing.T) {
+	t.Run("Should start and stop jobs without restarting the Scheduler", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ran := make(chan string)
+
+		s, err := NewScheduler(10)
+		require.NoError(t, err)
+
+		require.NoError(t, s.Schedule(Every(time.Millisecond), signalJob{name: "first", ran: ran}))
+
+		done := make(chan empty)
+		go func() {
+			s.Start(ctx)
+			close(done)
+		}()
+
+		require.Equal(t, "first", <-ran)
+
+		require.NoError(t, s.Schedule(Every(time.Millisecond), signalJob{name: "second", ran: ran}))
+		require.NoError(t, s.Remove("first"))
+
+		// Drain any run of first which was in-flight while it was removed
+		for name := range ran {
+			if name == "second" {
+				break
+			}
+		}
+
+		require.ErrorIs(t, s.Remove("first"), job.ErrJobDoesNotExist)
+
+		cancel()
+		<-done
+	})
+}
+
+type blockingJob struct {
+	name    string
+	started chan empty
+	release chan empty
+	ctxErr  chan error
+}
+
+func (bj blockingJob) Name() string {
+	return bj.name
+}
+
+func (bj blockingJob) Runner() JobFn {
+	// Only the first run is reported, later runs are released right away once release is closed
+	return func(ctx context.Context) error {
+		select {
+		case bj.started <- empty{}:
+		default:
+		}
+
+		<-bj.release
+
+		select {
+		case bj.ctxErr <- ctx.Err():
+		default:
+		}
+
+		return nil
+	}
+}
+
+func TestScheduler_Shutdown(t *testing.T) {
+	t.Run("Should let in-flight runs finish with their context intact", func(t *testing.T) {
+		bj := blockingJob{
+			name:    "blocker",
+			started: make(chan empty, 1),
+			release: make(chan empty),
+			ctxErr:  make(chan error, 1),
+		}
+
+		s, err := NewScheduler(1)
+		require.NoError(t, err)
+		require.NoError(t, s.Schedule(Every(time.Millisecond), bj))
+
+		done := make(chan empty)
+		go func() {
+			s.Start(context.Background())
+			close(done)
+		}()
+
+		<-bj.started
+
+		{
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			err := s.Shutdown(ctx)
+			require.ErrorIs(t, err, ErrShutdownTimeout)
+			require.EqualError(t, err, fmt.Errorf("%w:%s", ErrShutdownTimeout, "blocker").Error())
+		}
+
+		close(bj.release)
+		require.NoError(t, <-bj.ctxErr)
+
+		require.NoError(t, s.Shutdown(context.Background()))
+		<-done
+	})
+}
+
+func TestScheduler_acquire(t *testing.T) {
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := map[string]struct {
+		ctx     context.Context
+		full    bool
+		maxWait time.Duration
+		want    error
+	}{
+		"Should acquire a slot when the pool is not saturated": {
+			ctx:  context.Background(),
+			want: nil,
+		},
+		"Should return ErrPoolSaturated when max wait elapses": {
+			ctx:     context.Background(),
+			full:    true,
+			maxWait: time.Millisecond,
+			want:    ErrPoolSaturated,
+		},
+		"Should return context error when context is done": {
+			ctx:  cancelledCtx,
+			full: true,
+			want: context.Canceled,
+		},
+		"Should return context error and free the slot when context is done with a free slot": {
+			ctx:  cancelledCtx,
+			want: context.Canceled,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := NewScheduler(1)
+			require.NoError(t, err)
+
+			if tt.full {
+				require.NoError(t, s.acquire(context.Background(), 0, PriorityNormal))
+			}
+
+			require.Equal(t, tt.want, s.acquire(tt.ctx, tt.maxWait, PriorityNormal))
+
+			if tt.want != nil {
+				assert.Equal(t, !tt.full, s.jobPool.tryAcquire(PriorityNormal))
+			}
+		})
+	}
+}
+
+// concurrencyJob records the most runs of the jobs sharing running and peak in progress at once
+type concurrencyJob struct {
+	name    string
+	running *int32
+	peak    *int32
+	runs    chan empty
+}
+
+func (cj concurrencyJob) Name() string {
+	return cj.name
+}
+
+func (cj concurrencyJob) Runner() JobFn {
+	return func(ctx context.Context) error {
+		n := atomic.AddInt32(cj.running, 1)
+		defer atomic.AddInt32(cj.running, -1)
+
+		for {
+			peak := atomic.LoadInt32(cj.peak)
+			if n <= peak || atomic.CompareAndSwapInt32(cj.peak, peak, n) {
+				break
+			}
+		}
+
+		time.Sleep(5 * time.Millisecond)
+
+		select {
+		case cj.runs <- empty{}:
+		default:
+		}
+
+		return nil
+	}
+}
+
+func TestScheduler_OverlapPolicy(t *testing.T) {
+	tests := map[string]struct {
+		policy OverlapPolicy
+		want   int32
+		// wantSkipped is true when runs due while the previous one is in progress are skipped
+		wantSkipped bool
+	}{
+		"Should never run a job concurrently with OverlapQueue": {
+			policy: OverlapQueue,
+			want:   1,
+		},
+		"Should skip runs due while the previous one is in progress with OverlapSkip": {
+			policy:      OverlapSkip,
+			want:        1,
+			wantSkipped: true,
+		},
+		"Should run n instances of a job concurrently with OverlapAllow": {
+			policy:      OverlapAllow(3),
+			want:        3,
+			wantSkipped: true,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var running, peak int32
+			cj := concurrencyJob{name: "concurrency", running: &running, peak: &peak, runs: make(chan empty)}
+
+			rl := &recordingListener{}
+
+			s, err := NewScheduler(10, WithListener(rl))
+			require.NoError(t, err)
+			require.NoError(t, s.Schedule(Every(time.Millisecond), cj, WithOverlapPolicy(tt.policy)))
+
+			done := make(chan empty)
+			go func() {
+				s.Start(context.Background())
+				close(done)
+			}()
+
+			for i := 0; i < 5; i++ {
+				<-cj.runs
+			}
+
+			require.NoError(t, s.Shutdown(context.Background()))
+			<-done
+
+			assert.Equal(t, tt.want, atomic.LoadInt32(&peak))
+
+			rl.mu.Lock()
+			defer rl.mu.Unlock()
+			if tt.wantSkipped {
+				assert.Contains(t, rl.events, "skipped:previous run in progress")
+			} else {
+				assert.NotContains(t, rl.events, "skipped:previous run in progress")
+			}
+		})
+	}
+}
+
+// slowFirstJob sleeps for d on its first run only and records the start of every run
+type slowFirstJob struct {
+	d      time.Duration
+	mu     *sync.Mutex
+	starts *[]time.Time
+}
+
+func (sj slowFirstJob) Name() string {
+	return "slow-first"
+}
+
+func (sj slowFirstJob) Runner() JobFn {
+	return func(ctx context.Context) error {
+		sj.mu.Lock()
+		*sj.starts = append(*sj.starts, time.Now())
+		first := len(*sj.starts) == 1
+		sj.mu.Unlock()
+
+		if first {
+			time.Sleep(sj.d)
+		}
+
+		return nil
+	}
+}
+
+func TestScheduler_OverlapQueueBacklog(t *testing.T) {
+	tests := map[string]struct {
+		opts []SchedulerOption
+	}{
+		"Should fire the runs due while the previous one was in progress once with MisfireFireAll": {},
+		"Should fire the runs due while the previous one was in progress once with MisfireFireAll with the heap dispatcher": {
+			opts: []SchedulerOption{WithHeapDispatcher()},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var mu sync.Mutex
+			var starts []time.Time
+			sj := slowFirstJob{d: 200 * time.Millisecond, mu: &mu, starts: &starts}
+
+			s, err := NewScheduler(1, tt.opts...)
+			require.NoError(t, err)
+			require.NoError(t, s.Schedule(Every(10*time.Millisecond), sj, WithOverlapPolicy(OverlapQueue), WithMisfirePolicy(MisfireFireAll)))
+
+			done := startScheduler(t, s)
+			time.Sleep(300 * time.Millisecond)
+
+			require.NoError(t, s.Shutdown(context.Background()))
+			<-done
+
+			mu.Lock()
+			defer mu.Unlock()
+			require.NotEmpty(t, starts)
+
+			// About 20 runs became due during the first one, they must not fire back to back once it completed
+			completed := starts[0].Add(sj.d)
+
+			var backlog int
+			for _, start := range starts[1:] {
+				if start.Sub(completed) < 5*time.Millisecond {
+					backlog++
+				}
+			}
+
+			// The next occurrence may be due right after the single pending run
+			assert.LessOrEqual(t, backlog, 2)
+		})
+	}
+}
+
+type timedJob struct {
+	d    time.Duration
+	mu   *sync.Mutex
+	runs *[][2]time.Time
+}
+
+func (tj timedJob) Name() string {
+	return "timed"
+}
+
+func (tj timedJob) Runner() JobFn {
+	return func(ctx context.Context) error {
+		start := time.Now()
+		time.Sleep(tj.d)
+
+		tj.mu.Lock()
+		defer tj.mu.Unlock()
+
+		*tj.runs = append(*tj.runs, [2]time.Time{start, time.Now()})
+
+		return nil
+	}
+}
+
+func TestScheduler_FixedDelay(t *testing.T) {
+	tests := map[string]struct {
+		opts []SchedulerOption
+	}{
+		"Should run the next run a delay after the previous one completed": {},
+		"Should run the next run a delay after the previous one completed with the heap dispatcher": {
+			opts: []SchedulerOption{WithHeapDispatcher()},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu   sync.Mutex
+				runs [][2]time.Time
+			)
+
+			delay := 20 * time.Millisecond
+
+			s, err := NewScheduler(10, tt.opts...)
+			require.NoError(t, err)
+			require.NoError(t, s.Schedule(Every(delay), timedJob{d: 30 * time.Millisecond, mu: &mu, runs: &runs}, WithFixedDelay()))
+
+			done := startScheduler(t, s)
+
+			require.Eventually(t, func() bool {
+				mu.Lock()
+				defer mu.Unlock()
+
+				return len(runs) >= 4
+			}, 5*time.Second, time.Millisecond)
+
+			require.NoError(t, s.Shutdown(context.Background()))
+			<-done
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			for i := 1; i < len(runs); i++ {
+				assert.GreaterOrEqual(t, int64(runs[i][0].Sub(runs[i-1][1])), int64(delay-time.Millisecond))
+			}
+		})
+	}
+}
+
+func TestScheduler_RunOnStart(t *testing.T) {
+	tests := map[string]struct {
+		opts    []SchedulerOption
+		jobOpts []JobOption
+		want    bool
+	}{
+		"Should run a job on start": {
+			jobOpts: []JobOption{WithRunOnStart(true)},
+			want:    true,
+		},
+		"Should run a job on start with the heap dispatcher": {
+			opts:    []SchedulerOption{WithHeapDispatcher()},
+			jobOpts: []JobOption{WithRunOnStart(true)},
+			want:    true,
+		},
+		"Should run a job on start by default": {
+			opts: []SchedulerOption{WithRunOnStartDefault()},
+			want: true,
+		},
+		"Should let a job opt out of running on start by default": {
+			opts:    []SchedulerOption{WithRunOnStartDefault()},
+			jobOpts: []JobOption{WithRunOnStart(false)},
+		},
+		"Should wait for the timer without running on start": {},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ran := make(chan string, 1)
+
+			s, err := NewScheduler(1, tt.opts...)
+			require.NoError(t, err)
+			require.NoError(t, s.Schedule(Every(time.Hour), signalJob{name: "warm", ran: ran}, tt.jobOpts...))
+
+			done := startScheduler(t, s)
+			defer func() {
+				require.NoError(t, s.Shutdown(context.Background()))
+				<-done
+			}()
+
+			select {
+			case <-ran:
+				require.True(t, tt.want, "ran on start")
+			case <-time.After(100 * time.Millisecond):
+				require.False(t, tt.want, "did not run on start")
+			}
+
+			statuses := s.Status()
+			require.Len(t, statuses, 1)
+			assert.WithinDuration(t, time.Now().Add(time.Hour), statuses[0].NextRun, time.Second)
+		})
+	}
+}
+
+func TestScheduler_StartupStagger(t *testing.T) {
+	tests := map[string]struct {
+		opts []SchedulerOption
+	}{
+		"Should space the runs on start apart":                          {},
+		"Should space the runs on start apart with the heap dispatcher": {opts: []SchedulerOption{WithHeapDispatcher()}},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			stagger := 50 * time.Millisecond
+			ran := make(chan string, 3)
+
+			s, err := NewScheduler(3, append(tt.opts, WithRunOnStartDefault(), WithStartupStagger(stagger))...)
+			require.NoError(t, err)
+
+			for _, name := range []string{"first", "second", "third"} {
+				require.NoError(t, s.Schedule(Every(time.Hour), signalJob{name: name, ran: ran}))
+			}
+
+			start := time.Now()
+			done := startScheduler(t, s)
+
+			var at []time.Duration
+			for i := 0; i < 3; i++ {
+				select {
+				case <-ran:
+					at = append(at, time.Since(start))
+				case <-time.After(5 * time.Second):
+					require.Fail(t, "runs on start did not run")
+				}
+			}
+
+			require.NoError(t, s.Shutdown(context.Background()))
+			<-done
+
+			assert.GreaterOrEqual(t, int64(at[1]), int64(stagger-5*time.Millisecond))
+			assert.GreaterOrEqual(t, int64(at[2]), int64(2*stagger-5*time.Millisecond))
+		})
+	}
+}
+
+type errJob struct {
+	err error
+}
+
+func (ej errJob) Name() string {
+	return "errjob"
+}
+
+func (ej errJob) Runner() JobFn {
+	return func(ctx context.Context) error {
+		return ej.err
+	}
+}
+
+// startScheduler starts s in the background and returns once it is started
+// The returned channel is closed when Start returns
+func startScheduler(t *testing.T, s *Scheduler) <-chan empty {
+	done := make(chan empty)
+	go func() {
+		s.Start(context.Background())
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		return s.ctx != nil
+	}, time.Second, time.Millisecond)
+
+	return done
+}
+
+func TestScheduler_Trigger(t *testing.T) {
+	t.Run("Should return the error of the run when waiting", func(t *testing.T) {
+		s, err := NewScheduler(1)
+		require.NoError(t, err)
+		require.NoError(t, s.Schedule(Every(time.Hour), errJob{err: fmt.Errorf("custom_err")}))
+
+		require.EqualError(t, s.Trigger(context.Background(), "errjob", TriggerWait()), "custom_err")
+	})
+	t.Run("Should return ErrJobDoesNotExist when the job is not scheduled", func(t *testing.T) {
+		s, err := NewScheduler(1)
+		require.NoError(t, err)
+
+		require.ErrorIs(t, s.Trigger(context.Background(), "errjob"), job.ErrJobDoesNotExist)
+	})
+	t.Run("Should run a started job out-of-band", func(t *testing.T) {
+		ran := make(chan string)
+
+		s, err := NewScheduler(1)
+		require.NoError(t, err)
+		require.NoError(t, s.Schedule(Every(time.Hour), signalJob{name: "hourly", ran: ran}))
+
+		done := startScheduler(t, s)
+
+		require.NoError(t, s.Trigger(context.Background(), "hourly", TriggerResetTimer()))
+		require.Equal(t, "hourly", <-ran)
+
+		require.NoError(t, s.Shutdown(context.Background()))
+		<-done
+	})
+	t.Run("Should run a started job with the context of Start once the caller returns", func(t *testing.T) {
+		rl := &recordingListener{}
+
+		s, err := NewScheduler(1, WithListener(rl))
+		require.NoError(t, err)
+		require.NoError(t, s.Schedule(Every(time.Hour), sleepJob{d: 20 * time.Millisecond}))
+
+		done := startScheduler(t, s)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		require.NoError(t, s.Trigger(ctx, "sleeper"))
+		require.Eventually(t, func() bool { return s.Status()[0].State == StateRunning }, time.Second, time.Millisecond)
+		cancel()
+
+		require.NoError(t, s.Shutdown(context.Background()))
+		<-done
+
+		rl.mu.Lock()
+		defer rl.mu.Unlock()
+		assert.Contains(t, rl.events, "completed")
+		assert.NoError(t, rl.last.Err)
+	})
+	t.Run("Should report a run dropped while waiting for a slot", func(t *testing.T) {
+		rl := &recordingListener{}
+
+		s, err := NewScheduler(1, WithListener(rl))
+		require.NoError(t, err)
+		require.NoError(t, s.Schedule(Every(time.Hour), errJob{}))
+		require.NoError(t, s.acquire(context.Background(), 0, PriorityNormal))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		require.ErrorIs(t, s.Trigger(ctx, "errjob", TriggerWait()), context.Canceled)
+		assert.Equal(t, []string{"scheduled", "queued", "skipped:context canceled"}, rl.events)
+	})
+	t.Run("Should wait for a triggered run on Shutdown", func(t *testing.T) {
+		s, err := NewScheduler(1)
+		require.NoError(t, err)
+		require.NoError(t, s.Schedule(Every(time.Hour), sleepJob{d: 20 * time.Millisecond}))
+
+		done := startScheduler(t, s)
+
+		triggered := make(chan empty)
+		go func() {
+			assert.NoError(t, s.Trigger(context.Background(), "sleeper", TriggerWait()))
+			close(triggered)
+		}()
+		require.Eventually(t, func() bool { return s.Status()[0].State == StateRunning }, time.Second, time.Millisecond)
+
+		require.NoError(t, s.Shutdown(context.Background()))
+		<-done
+
+		js := s.Status()[0]
+		assert.Equal(t, StateIdle, js.State)
+		assert.False(t, js.LastEnd.IsZero(), "Shutdown returned before the triggered run completed")
+
+		<-triggered
+	})
+	t.Run("Should preserve the timer unless reset", func(t *testing.T) {
+		s, err := NewScheduler(1)
+		require.NoError(t, err)
+		require.NoError(t, s.Schedule(Every(time.Hour), errJob{}))
+
+		done := startScheduler(t, s)
+
+		var scheduled time.Time
+		require.Eventually(t, func() bool {
+			scheduled = s.Status()[0].NextRun
+			return !scheduled.IsZero()
+		}, time.Second, time.Millisecond)
+
+		time.Sleep(time.Millisecond)
+
+		require.NoError(t, s.Trigger(context.Background(), "errjob", TriggerWait()))
+		assert.Equal(t, scheduled, s.Status()[0].NextRun)
+
+		require.NoError(t, s.Trigger(context.Background(), "errjob", TriggerWait(), TriggerResetTimer()))
+		require.Eventually(t, func() bool { return s.Status()[0].NextRun.After(scheduled) }, time.Second, time.Millisecond)
+
+		require.NoError(t, s.Shutdown(context.Background()))
+		<-done
+	})
+}
+
+func TestScheduler_PauseResume(t *testing.T) {
+	t.Run("Should not run a paused job until it is resumed", func(t *testing.T) {
+		ran := make(chan string)
+
+		s, err := NewScheduler(1)
+		require.NoError(t, err)
+		require.NoError(t, s.Schedule(Every(time.Millisecond), signalJob{name: "paused", ran: ran}))
+		require.NoError(t, s.Pause("paused"))
+
+		done := startScheduler(t, s)
+
+		select {
+		case <-ran:
+			require.Fail(t, "paused job should not run")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		require.NoError(t, s.Resume("paused", ResumeCatchUp()))
+		require.Equal(t, "paused", <-ran)
+
+		// Drain a run which may have started before the job was paused again
+		go func() {
+			for range ran {
+			}
+		}()
+
+		require.NoError(t, s.Pause("paused"))
+		require.NoError(t, s.Shutdown(context.Background()))
+		<-done
+	})
+	t.Run("Should return ErrJobDoesNotExist when the job is not scheduled", func(t *testing.T) {
+		s, err := NewScheduler(1)
+		require.NoError(t, err)
+
+		require.ErrorIs(t, s.Pause("missing"), job.ErrJobDoesNotExist)
+		require.ErrorIs(t, s.Resume("missing"), job.ErrJobDoesNotExist)
+	})
+}
+
+type recordingListener struct {
+	NoopListener
+	mu     sync.Mutex
+	events []string
+	last   Event
+}
+
+func (rl *recordingListener) record(name string, e Event) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.events = append(rl.events, name)
+	rl.last = e
+}
+
+func (rl *recordingListener) OnScheduled(_ context.Context, e Event) { rl.record("scheduled", e) }
+
+func (rl *recordingListener) OnQueued(_ context.Context, e Event) { rl.record("queued", e) }
+
+func (rl *recordingListener) OnStarted(_ context.Context, e Event) { rl.record("started", e) }
+
+func (rl *recordingListener) OnCompleted(_ context.Context, e Event) { rl.record("completed", e) }
+
+func (rl *recordingListener) OnFailed(_ context.Context, e Event) { rl.record("failed", e) }
+
+func (rl *recordingListener) OnPanic(_ context.Context, e Event) { rl.record("panic", e) }
+
+func (rl *recordingListener) OnTimedOut(_ context.Context, e Event) { rl.record("timedout", e) }
+
+func (rl *recordingListener) OnSkipped(_ context.Context, e Event) { rl.record("skipped:"+e.Reason, e) }
+
+type panicJob struct{}
+
+func (panicJob) Name() string {
+	return "panicker"
+}
+
+func (panicJob) Runner() JobFn {
+	return func(ctx context.Context) error {
+		panic("panicking")
+	}
+}
+
+// sleepJob sleeps for d, it returns early when its context is done unless ignoreCtx is set
+type sleepJob struct {
+	d         time.Duration
+	ignoreCtx bool
+}
+
+func (sj sleepJob) Name() string {
+	return "sleeper"
+}
+
+func (sj sleepJob) Runner() JobFn {
+	return func(ctx context.Context) error {
+		if sj.ignoreCtx {
+			time.Sleep(sj.d)
+			return nil
+		}
+
+		select {
+		case <-time.After(sj.d):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func TestScheduler_Listener(t *testing.T) {
+	tests := map[string]struct {
+		job        job.Job
+		opts       []JobOption
+		wantEvents []string
+		wantErr    error
+	}{
+		"Should report a completed run": {
+			job:        errJob{},
+			wantEvents: []string{"scheduled", "queued", "started", "completed"},
+		},
+		"Should report a failed run": {
+			job:        errJob{err: fmt.Errorf("custom_err")},
+			wantEvents: []string{"scheduled", "queued", "started", "failed"},
+			wantErr:    fmt.Errorf("custom_err"),
+		},
+		"Should report a panicked run": {
+			job:        panicJob{},
+			wantEvents: []string{"scheduled", "queued", "started", "panic"},
+			wantErr:    fmt.Errorf("%w:%s", ErrJobPanicked, "panicking"),
+		},
+		"Should report a timed out run": {
+			job:        sleepJob{d: time.Second},
+			opts:       []JobOption{WithTimeout(10 * time.Millisecond)},
+			wantEvents: []string{"scheduled", "queued", "started", "timedout"},
+			wantErr:    fmt.Errorf("%w:exceeded 10ms:%s", ErrJobTimedOut, context.DeadlineExceeded),
+		},
+		"Should report an abandoned run": {
+			job:        sleepJob{d: 200 * time.Millisecond, ignoreCtx: true},
+			opts:       []JobOption{WithTimeout(10 * time.Millisecond), WithAbandonAfter(10 * time.Millisecond)},
+			wantEvents: []string{"scheduled", "queued", "started", "timedout"},
+			wantErr:    fmt.Errorf("%w:still running 10ms after its timeout of 10ms", ErrJobAbandoned),
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rl := &recordingListener{}
+
+			s, err := NewScheduler(1, WithListener(rl), WithRunIDGenerator(func(j job.Job) string { return "run-id" }))
+			require.NoError(t, err)
+			require.NoError(t, s.Schedule(Every(time.Hour), tt.job, tt.opts...))
+
+			err = s.Trigger(context.Background(), tt.job.Name(), TriggerWait())
+			if tt.wantErr != nil {
+				require.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantEvents, rl.events)
+			assert.Equal(t, "run-id", rl.last.RunID)
+			assert.Equal(t, tt.job.Name(), rl.last.Job)
+			assert.Equal(t, err, rl.last.Err)
+		})
+	}
+}
+
+// recordingNextRunListener records the next runs computed on top of the events of recordingListener
+// It is slow to record a scheduled job so events sent while it is notified are recorded first
+type recordingNextRunListener struct {
+	*recordingListener
+}
+
+func (nl recordingNextRunListener) OnScheduled(_ context.Context, e Event) {
+	time.Sleep(10 * time.Millisecond)
+	nl.record("scheduled", e)
+}
+
+func (nl recordingNextRunListener) OnNextRunComputed(_ context.Context, e Event) {
+	nl.record("next", e)
+}
+
+func TestScheduler_ListenerScheduled(t *testing.T) {
+	tests := map[string]struct {
+		opts []SchedulerOption
+	}{
+		"Should report a job scheduled while started before its other events": {},
+		"Should report a job scheduled while started before its other events with the heap dispatcher": {
+			opts: []SchedulerOption{WithHeapDispatcher()},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rl := &recordingListener{}
+
+			s, err := NewScheduler(1, append(tt.opts, WithListener(recordingNextRunListener{rl}))...)
+			require.NoError(t, err)
+
+			done := startScheduler(t, s)
+			require.NoError(t, s.Schedule(Every(time.Hour), errJob{}, WithRunOnStart(true)))
+
+			require.Eventually(t, func() bool {
+				rl.mu.Lock()
+				defer rl.mu.Unlock()
+
+				return len(rl.events) >= 3
+			}, time.Second, time.Millisecond)
+
+			require.NoError(t, s.Shutdown(context.Background()))
+			<-done
+
+			rl.mu.Lock()
+			defer rl.mu.Unlock()
+			assert.Equal(t, "scheduled", rl.events[0])
+		})
+	}
+}
+
+func TestScheduler_Timeout(t *testing.T) {
+	tests := map[string]struct {
+		opts    []SchedulerOption
+		job     sleepJob
+		jobOpts []JobOption
+		wantErr error
+	}{
+		"Should time out a run after the default timeout": {
+			opts:    []SchedulerOption{WithDefaultTimeout(10 * time.Millisecond)},
+			job:     sleepJob{d: time.Second},
+			wantErr: ErrJobTimedOut,
+		},
+		"Should let a job override the default timeout": {
+			opts:    []SchedulerOption{WithDefaultTimeout(10 * time.Millisecond)},
+			job:     sleepJob{d: 20 * time.Millisecond},
+			jobOpts: []JobOption{WithTimeout(-1)},
+		},
+		"Should time out a run which ignores its context": {
+			job:     sleepJob{d: 20 * time.Millisecond, ignoreCtx: true},
+			jobOpts: []JobOption{WithTimeout(10 * time.Millisecond)},
+			wantErr: ErrJobTimedOut,
+		},
+		"Should not time out a run which completes in time": {
+			job:     sleepJob{d: time.Millisecond},
+			jobOpts: []JobOption{WithTimeout(time.Second)},
+		},
+		"Should abandon a run after the default grace period": {
+			opts:    []SchedulerOption{WithDefaultTimeout(10 * time.Millisecond), WithDefaultAbandonAfter(10 * time.Millisecond)},
+			job:     sleepJob{d: time.Second, ignoreCtx: true},
+			wantErr: ErrJobAbandoned,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := NewScheduler(1, tt.opts...)
+			require.NoError(t, err)
+			require.NoError(t, s.Schedule(Every(time.Hour), tt.job, tt.jobOpts...))
+
+			err = s.Trigger(context.Background(), tt.job.Name(), TriggerWait())
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			statuses := s.Status()
+			require.Len(t, statuses, 1)
+			assert.Equal(t, err, statuses[0].LastErr)
+		})
+	}
+	t.Run("Should free the slot of an abandoned run", func(t *testing.T) {
+		s, err := NewScheduler(1)
+		require.NoError(t, err)
+		require.NoError(t, s.Schedule(
+			Every(time.Hour),
+			sleepJob{d: time.Second, ignoreCtx: true},
+			WithTimeout(10*time.Millisecond),
+			WithAbandonAfter(10*time.Millisecond),
+		))
+		require.NoError(t, s.Schedule(Every(time.Hour), errJob{}))
+
+		require.ErrorIs(t, s.Trigger(context.Background(), "sleeper", TriggerWait()), ErrJobAbandoned)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+
+		require.NoError(t, s.Trigger(ctx, "errjob", TriggerWait()))
+	})
+}
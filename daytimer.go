@@ -0,0 +1,263 @@
+package cronalt
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ahmedalhulaibi/cronalt/internal/wallclock"
+)
+
+var ErrInvalidDayTimer error = fmt.Errorf("invalid day timer")
+
+// maxDaySearch bounds how many days DayTimer.Next looks ahead for a matching day, e.g. OnDay(30) skips February
+const maxDaySearch = 5 * 366
+
+// DayTimer runs on selected days at selected times of day, it is built with Daily, Weekdays, Weekly or Monthly
+// e.g. Weekly(time.Monday, time.Thursday).At("18:00")
+// Times are read off the wall clock of the location set with In, default is the location of the previous run,
+// and behave like a cron expression around daylight saving time transitions, see CronIn
+type DayTimer struct {
+	// weekdays is a bitset of the weekdays to run on, zero with monthDays unset runs every day
+	weekdays uint8
+	// monthDays are the days of the month to run on, negative days count from the end of the month
+	monthDays []int
+
+	// at are the times of day to run at as durations since midnight
+	at []time.Duration
+	// from, to and every run on an interval between two times of day when every is set
+	from, to, every time.Duration
+
+	loc *time.Location
+	err error
+}
+
+var _ jobTimer = (*DayTimer)(nil)
+
+// Daily returns a DayTimer which runs every day, at midnight unless At or Every is set
+func Daily() DayTimer {
+	return DayTimer{}
+}
+
+// Weekdays returns a DayTimer which runs Monday to Friday
+func Weekdays() DayTimer {
+	return Weekly(time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday)
+}
+
+// Weekly returns a DayTimer which runs on the given days of the week
+func Weekly(days ...time.Weekday) DayTimer {
+	var d DayTimer
+	if len(days) == 0 {
+		d.err = fmt.Errorf("%w:%s", ErrInvalidDayTimer, "Weekly without days")
+	}
+
+	for _, day := range days {
+		if day < time.Sunday || day > time.Saturday {
+			d.err = fmt.Errorf("%w:weekday %d", ErrInvalidDayTimer, day)
+			continue
+		}
+
+		d.weekdays |= 1 << uint(day)
+	}
+
+	return d
+}
+
+// Monthly returns a DayTimer which runs on the first day of every month unless OnDay is set
+func Monthly() DayTimer {
+	return DayTimer{monthDays: []int{1}}
+}
+
+// OnDay sets the days of the month a Monthly timer runs on, negative days count back from the end of the month
+// e.g. OnDay(-1) is the last day of the month, months without the day are skipped
+func (d DayTimer) OnDay(days ...int) DayTimer {
+	d.monthDays = nil
+
+	for _, day := range days {
+		if day == 0 || day < -31 || day > 31 {
+			d.err = fmt.Errorf("%w:day of month %d", ErrInvalidDayTimer, day)
+			continue
+		}
+
+		d.monthDays = append(d.monthDays, day)
+	}
+
+	if len(d.monthDays) == 0 && d.err == nil {
+		d.err = fmt.Errorf("%w:%s", ErrInvalidDayTimer, "OnDay without days")
+	}
+
+	return d
+}
+
+// At sets the times of day to run at as "15:04" or "15:04:05"
+func (d DayTimer) At(times ...string) DayTimer {
+	d.at = nil
+
+	for _, s := range times {
+		t, err := parseTimeOfDay(s)
+		if err != nil {
+			d.err = err
+			continue
+		}
+
+		d.at = append(d.at, t)
+	}
+
+	sort.Slice(d.at, func(i, j int) bool { return d.at[i] < d.at[j] })
+
+	return d
+}
+
+// Between restricts Every to the times of day between from and to inclusive, as "15:04" or "15:04:05"
+func (d DayTimer) Between(from, to string) DayTimer {
+	var err error
+
+	if d.from, err = parseTimeOfDay(from); err != nil {
+		d.err = err
+	}
+
+	if d.to, err = parseTimeOfDay(to); err != nil {
+		d.err = err
+	}
+
+	if d.from > d.to {
+		d.err = fmt.Errorf("%w:%s is after %s", ErrInvalidDayTimer, from, to)
+	}
+
+	if d.every == 0 {
+		d.every = time.Hour
+	}
+
+	return d
+}
+
+// Every runs on an interval starting at the beginning of Between, or midnight, until its end instead of the times set with At
+// Between without Every runs hourly
+func (d DayTimer) Every(interval time.Duration) DayTimer {
+	if interval <= 0 {
+		d.err = fmt.Errorf("%w:interval %s", ErrInvalidDayTimer, interval)
+	}
+
+	if d.every == 0 && d.to == 0 {
+		d.to = 24*time.Hour - time.Nanosecond
+	}
+
+	d.every = interval
+
+	return d
+}
+
+// In sets the location whose wall clock the times of day are read off
+func (d DayTimer) In(loc *time.Location) DayTimer {
+	d.loc = loc
+	return d
+}
+
+// Err returns the first error building the timer, a DayTimer with an error never runs and is rejected by Schedule
+func (d DayTimer) Err() error {
+	return d.err
+}
+
+func (d DayTimer) Next(prevStart time.Time) time.Time {
+	if d.err != nil {
+		return time.Time{}
+	}
+
+	loc := d.loc
+	if loc == nil {
+		loc = prevStart.Location()
+	}
+
+	return wallclock.In(dayWallTimer{d}, loc).Next(prevStart)
+}
+
+// dayWallTimer matches the DayTimer against wall clock times
+type dayWallTimer struct {
+	DayTimer
+}
+
+func (d dayWallTimer) Next(w time.Time) time.Time {
+	day := time.Date(w.Year(), w.Month(), w.Day(), 0, 0, 0, 0, time.UTC)
+	after := w.Sub(day)
+
+	for i := 0; i < maxDaySearch; i++ {
+		if d.runsOn(day) {
+			if t, ok := d.timeAfter(after); ok {
+				return day.Add(t)
+			}
+		}
+
+		day = day.AddDate(0, 0, 1)
+		after = -1
+	}
+
+	return time.Time{}
+}
+
+func (d DayTimer) runsOn(day time.Time) bool {
+	switch {
+	case d.monthDays != nil:
+		last := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		for _, md := range d.monthDays {
+			if md == day.Day() || md < 0 && last+md+1 == day.Day() {
+				return true
+			}
+		}
+
+		return false
+	case d.weekdays != 0:
+		return d.weekdays&(1<<uint(day.Weekday())) != 0
+	default:
+		return true
+	}
+}
+
+// timeAfter returns the first time of day the timer runs at after the time of day after
+func (d DayTimer) timeAfter(after time.Duration) (time.Duration, bool) {
+	if d.every > 0 {
+		t := d.from
+		if after >= d.from {
+			t = d.from + ((after-d.from)/d.every+1)*d.every
+		}
+
+		return t, t <= d.to
+	}
+
+	if len(d.at) == 0 {
+		return 0, after < 0
+	}
+
+	for _, t := range d.at {
+		if t > after {
+			return t, true
+		}
+	}
+
+	return 0, false
+}
+
+// parseTimeOfDay parses "15:04" or "15:04:05" into a duration since midnight
+func parseTimeOfDay(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("%w:time of day %q", ErrInvalidDayTimer, s)
+	}
+
+	max := []int{23, 59, 59}
+	unit := []time.Duration{time.Hour, time.Minute, time.Second}
+
+	var t time.Duration
+
+	for i, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil || len(p) != 2 || v < 0 || v > max[i] {
+			return 0, fmt.Errorf("%w:time of day %q", ErrInvalidDayTimer, s)
+		}
+
+		t += time.Duration(v) * unit[i]
+	}
+
+	return t, nil
+}
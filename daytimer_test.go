@@ -0,0 +1,169 @@
+package cronalt
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDayTimer_Next(t *testing.T) {
+	toronto, err := time.LoadLocation("America/Toronto")
+	require.NoError(t, err)
+
+	// 2021-01-01 is a Friday
+	startFixture := time.Date(2021, 01, 01, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		timer     DayTimer
+		prevStart time.Time
+		want      []time.Time
+	}{
+		"Should run daily at midnight by default": {
+			timer:     Daily(),
+			prevStart: startFixture,
+			want: []time.Time{
+				time.Date(2021, 01, 02, 0, 0, 0, 0, time.UTC),
+				time.Date(2021, 01, 03, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should run daily at a time of day": {
+			timer:     Daily().At("09:30"),
+			prevStart: time.Date(2021, 01, 01, 8, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 01, 01, 9, 30, 0, 0, time.UTC),
+				time.Date(2021, 01, 02, 9, 30, 0, 0, time.UTC),
+			},
+		},
+		"Should run daily at several times of day": {
+			timer:     Daily().At("18:00", "06:00:30"),
+			prevStart: startFixture,
+			want: []time.Time{
+				time.Date(2021, 01, 01, 18, 0, 0, 0, time.UTC),
+				time.Date(2021, 01, 02, 6, 0, 30, 0, time.UTC),
+				time.Date(2021, 01, 02, 18, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should run weekly on the given days": {
+			timer:     Weekly(time.Monday, time.Thursday).At("18:00"),
+			prevStart: startFixture,
+			want: []time.Time{
+				time.Date(2021, 01, 04, 18, 0, 0, 0, time.UTC),
+				time.Date(2021, 01, 07, 18, 0, 0, 0, time.UTC),
+				time.Date(2021, 01, 11, 18, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should run monthly on the first day by default": {
+			timer:     Monthly(),
+			prevStart: startFixture,
+			want: []time.Time{
+				time.Date(2021, 02, 01, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should run monthly on the last day of the month": {
+			timer:     Monthly().OnDay(-1).At("23:00"),
+			prevStart: startFixture,
+			want: []time.Time{
+				time.Date(2021, 01, 31, 23, 0, 0, 0, time.UTC),
+				time.Date(2021, 02, 28, 23, 0, 0, 0, time.UTC),
+				time.Date(2021, 03, 31, 23, 0, 0, 0, time.UTC),
+				time.Date(2021, 04, 30, 23, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should skip months without the day": {
+			timer:     Monthly().OnDay(30),
+			prevStart: startFixture,
+			want: []time.Time{
+				time.Date(2021, 01, 30, 0, 0, 0, 0, time.UTC),
+				time.Date(2021, 03, 30, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should run on weekdays between two times of day on an interval": {
+			timer:     Weekdays().Between("09:00", "17:00").Every(4 * time.Hour),
+			prevStart: startFixture,
+			want: []time.Time{
+				time.Date(2021, 01, 01, 13, 0, 0, 0, time.UTC),
+				time.Date(2021, 01, 01, 17, 0, 0, 0, time.UTC),
+				time.Date(2021, 01, 04, 9, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should run on an interval from midnight without Between": {
+			timer:     Daily().Every(10 * time.Hour),
+			prevStart: startFixture,
+			want: []time.Time{
+				time.Date(2021, 01, 01, 20, 0, 0, 0, time.UTC),
+				time.Date(2021, 01, 02, 0, 0, 0, 0, time.UTC),
+				time.Date(2021, 01, 02, 10, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should run hourly with Between only": {
+			timer:     Daily().Between("22:00", "23:00"),
+			prevStart: startFixture,
+			want: []time.Time{
+				time.Date(2021, 01, 01, 22, 0, 0, 0, time.UTC),
+				time.Date(2021, 01, 01, 23, 0, 0, 0, time.UTC),
+				time.Date(2021, 01, 02, 22, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should run on the wall clock of the location": {
+			timer:     Daily().At("09:00").In(toronto),
+			prevStart: time.Date(2021, 03, 13, 12, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 03, 13, 14, 0, 0, 0, time.UTC),
+				time.Date(2021, 03, 14, 13, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should run on the wall clock of the location of the previous run": {
+			timer:     Daily().At("09:00"),
+			prevStart: time.Date(2021, 03, 13, 12, 0, 0, 0, toronto),
+			want: []time.Time{
+				time.Date(2021, 03, 14, 9, 0, 0, 0, toronto),
+				time.Date(2021, 03, 15, 9, 0, 0, 0, toronto),
+			},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.NoError(t, tt.timer.Err())
+
+			prev := tt.prevStart
+			for _, want := range tt.want {
+				prev = tt.timer.Next(prev)
+				assert.True(t, want.Equal(prev), "want %s got %s", want, prev)
+			}
+		})
+	}
+}
+
+func TestDayTimer_Err(t *testing.T) {
+	tests := map[string]DayTimer{
+		"Should reject a time of day out of range":    Daily().At("24:00"),
+		"Should reject a time of day without minutes": Daily().At("9"),
+		"Should reject a malformed time of day":       Daily().At("9:30"),
+		"Should reject Weekly without days":           Weekly(),
+		"Should reject a day of month out of range":   Monthly().OnDay(32),
+		"Should reject a day of month of zero":        Monthly().OnDay(0),
+		"Should reject a backwards Between":           Daily().Between("17:00", "09:00"),
+		"Should reject a zero interval":               Daily().Every(0),
+	}
+	for name, timer := range tests {
+		timer := timer
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.True(t, errors.Is(timer.Err(), ErrInvalidDayTimer))
+			assert.True(t, timer.Next(time.Now()).IsZero())
+
+			s, err := NewScheduler(1)
+			require.NoError(t, err)
+			assert.True(t, errors.Is(s.Schedule(timer, signalJob{name: "invalid"}), ErrInvalidDayTimer))
+		})
+	}
+}
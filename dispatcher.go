@@ -0,0 +1,224 @@
+package cronalt
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// dispatcher drives the scheduled runs of started jobs
+// Its methods are called with Scheduler.mu held and must not block
+type dispatcher interface {
+	// start starts scheduling the runs of a job
+	start(cfg jobCfg)
+	// remove stops scheduling the runs of a job, a run in progress is left to finish
+	remove(name string)
+	// reset reschedules the next run of a job from prev
+	reset(name string, prev time.Time)
+	// resume wakes up a job which missed a run while it was paused, catchUp runs it immediately
+	resume(name string, catchUp bool)
+}
+
+// loopDispatcher runs each job in its own goroutine with its own timer
+type loopDispatcher struct {
+	s *Scheduler
+	// ctx is passed on to each run while loopCtx is the parent context of all job loops
+	ctx     context.Context
+	loopCtx context.Context
+	// loops holds the controls of each running job loop keyed by job name
+	loops map[string]jobLoop
+}
+
+var _ dispatcher = (*loopDispatcher)(nil)
+
+func newLoopDispatcher(s *Scheduler, ctx, loopCtx context.Context) *loopDispatcher {
+	return &loopDispatcher{
+		s:       s,
+		ctx:     ctx,
+		loopCtx: loopCtx,
+		loops:   make(map[string]jobLoop),
+	}
+}
+
+// jobLoop holds the controls of a running job loop
+type jobLoop struct {
+	// cancel stops the loop
+	cancel context.CancelFunc
+	// reset reschedules the loop's next run from the time sent
+	reset chan time.Time
+	// resume wakes up a loop parked while its job was paused, true catches up on the missed run
+	resume chan bool
+}
+
+func (d *loopDispatcher) start(cfg jobCfg) {
+	name := cfg.Job().Name()
+
+	loopCtx, cancel := context.WithCancel(d.loopCtx)
+	l := jobLoop{
+		cancel: cancel,
+		reset:  make(chan time.Time, 1),
+		resume: make(chan bool, 1),
+	}
+	d.loops[name] = l
+
+	d.s.wg.Add(1)
+	d.s.log.Info(d.ctx, "cronalt.Scheduler starting job", KeyVal{"job", name})
+	go d.s.run(d.ctx, loopCtx, cfg, l, d.s.startSlot(cfg))
+}
+
+func (d *loopDispatcher) remove(name string) {
+	if l, ok := d.loops[name]; ok {
+		l.cancel()
+		delete(d.loops, name)
+	}
+}
+
+func (d *loopDispatcher) reset(name string, prev time.Time) {
+	if l, ok := d.loops[name]; ok {
+		// Replace any pending reset so the latest one wins
+		select {
+		case <-l.reset:
+		default:
+		}
+
+		l.reset <- prev
+	}
+}
+
+func (d *loopDispatcher) resume(name string, catchUp bool) {
+	if l, ok := d.loops[name]; ok {
+		// Replace any pending resume so the latest call wins
+		select {
+		case <-l.resume:
+		default:
+		}
+
+		l.resume <- catchUp
+	}
+}
+
+// run is the loop of a single job, loopCtx stops the loop while ctx is passed on to each run
+// startAt is the time of its run on start, zero when the first run is computed by its timer
+func (s *Scheduler) run(ctx, loopCtx context.Context, cfg jobCfg, l jobLoop, startAt time.Time) {
+	defer s.wg.Done()
+
+	jobName := cfg.Job().Name()
+
+	next, wait := s.firstRun(ctx, cfg, startAt)
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	// parked is true when a run was missed because the job is paused, the timer is left expired until the job is resumed
+	var parked bool
+
+	// running counts the concurrent runs of the job when it is not run sequentially
+	var running int32
+
+	for {
+		if next.IsZero() {
+			// The timer has no further runs
+			return
+		}
+
+		select {
+		case <-loopCtx.Done():
+			s.log.Info(ctx, "cronalt.Scheduler halted", KeyVal{"job", jobName})
+			return
+		case prev := <-l.reset:
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+
+			parked = false
+			next, wait = s.getTimeUntilNextRun(ctx, prev, cfg)
+			timer.Reset(wait)
+		case catchUp := <-l.resume:
+			if !parked {
+				// Nothing was missed while the job was paused, the timer is still running
+				continue
+			}
+
+			parked = false
+
+			if catchUp {
+				next = s.clock.Now()
+				timer.Reset(0)
+				continue
+			}
+
+			next, wait = s.getTimeUntilNextRun(ctx, s.clock.Now(), cfg)
+			timer.Reset(wait)
+		case <-timer.C:
+			if s.missedWhilePaused(ctx, jobName) {
+				parked = true
+				continue
+			}
+
+			fired(cfg.Timer(), next)
+
+			nextCfg := cfg
+			if cfg.sequential() {
+				nextCfg = cfg.ranAt(s.clock.Now())
+				_ = s.runOnce(ctx, loopCtx, cfg)
+			} else {
+				s.runConcurrently(ctx, loopCtx, cfg, &running)
+			}
+
+			// The next run is computed from the scheduled time of this run rather than the time it fired,
+			// or from the time it completed with WithFixedDelay
+			prev := next
+			if cfg.fixedDelay {
+				prev = s.clock.Now()
+			}
+
+			next, wait = s.getTimeUntilNextRun(ctx, prev, nextCfg)
+
+			// Use timer.Reset since we know the timer is expired and we can reset it
+			timer.Reset(wait)
+		}
+	}
+}
+
+// firstPrev returns the time the first run of a job is scheduled from
+func (s *Scheduler) firstPrev(cfg jobCfg) time.Time {
+	if !cfg.lastRun.IsZero() {
+		return cfg.lastRun
+	}
+
+	return s.clock.Now()
+}
+
+// missedWhilePaused reports and returns true if a due run of the job is missed because the job is paused
+func (s *Scheduler) missedWhilePaused(ctx context.Context, jobName string) bool {
+	if !s.isPaused(jobName) {
+		return false
+	}
+
+	s.log.Info(ctx, "cronalt.Scheduler paused", KeyVal{"job", jobName})
+	s.notify(func(l Listener) {
+		l.OnSkipped(ctx, Event{Job: jobName, Time: s.clock.Now(), Reason: "job paused"})
+	})
+
+	return true
+}
+
+// runConcurrently runs a job in its own goroutine unless running already reached the limit of its OverlapPolicy
+func (s *Scheduler) runConcurrently(ctx, queueCtx context.Context, cfg jobCfg, running *int32) {
+	if atomic.LoadInt32(running) >= int32(cfg.overlap.limit) {
+		s.skipped(ctx, cfg.Job().Name(), "", "previous run in progress")
+		return
+	}
+
+	atomic.AddInt32(running, 1)
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+		defer atomic.AddInt32(running, -1)
+
+		_ = s.runOnce(ctx, queueCtx, cfg)
+	}()
+}
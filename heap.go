@@ -0,0 +1,485 @@
+package cronalt
+
+import (
+	"container/heap"
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// heapDispatcher drives all jobs from a single goroutine and timer
+// Next runs are kept in a min-heap, entries are only touched by the dispatcher goroutine
+// and other goroutines hand it work through do
+// Due runs wait in the dispatcher until it takes their slots in the job pool and their groups,
+// only then are they handed to a fixed set of maxConcurrentJobs workers, so a worker never waits for a slot
+type heapDispatcher struct {
+	s *Scheduler
+	// ctx is passed on to each run while loopCtx stops the dispatcher and its workers
+	ctx     context.Context
+	loopCtx context.Context
+
+	// mu guards commands
+	mu       sync.Mutex
+	commands []func()
+	wake     chan empty
+
+	// acquired hands the runs holding their slots to the workers, it never holds more runs than the job pool has slots
+	acquired chan *heapRun
+	// stopped is closed once the dispatcher goroutine returned and no further runs are handed to the workers
+	stopped chan empty
+
+	// queue, entries and waiting are owned by the dispatcher goroutine
+	queue   entryQueue
+	entries map[string]*heapEntry
+	// waiting holds the queued runs waiting for their slots
+	waiting []*heapRun
+}
+
+var _ dispatcher = (*heapDispatcher)(nil)
+
+// heapEntry is the scheduling state of a single job
+type heapEntry struct {
+	cfg jobCfg
+	// ctx stops queued runs of the job when it is removed
+	ctx    context.Context
+	cancel context.CancelFunc
+	// next is the scheduled time of the next run
+	next time.Time
+	// index is the position of the entry in the queue, -1 when it is not queued
+	index int
+	// parked is true when a run was missed because the job is paused
+	parked bool
+	// busy is true while a sequential run is in progress, it fired at firedAt
+	busy    bool
+	firedAt time.Time
+	// resetFrom holds a reset requested while busy, applied once the run completes
+	resetFrom time.Time
+	removed   bool
+	// finished is true once the timer of the job has no further runs, the entry is no longer queued
+	finished bool
+	// running counts the concurrent runs of the job when it is not run sequentially
+	running int32
+}
+
+// heapRun is a queued run of an entry
+type heapRun struct {
+	e     *heapEntry
+	runID string
+	// queuedAt is when the run was queued and deadline when its maximum queue wait elapses, zero waits indefinitely
+	queuedAt time.Time
+	deadline time.Time
+	// done is called once the run completed or was dropped
+	done func()
+}
+
+func newHeapDispatcher(s *Scheduler, ctx, loopCtx context.Context) *heapDispatcher {
+	d := &heapDispatcher{
+		s:        s,
+		ctx:      ctx,
+		loopCtx:  loopCtx,
+		wake:     make(chan empty, 1),
+		acquired: make(chan *heapRun, s.jobPool.size),
+		stopped:  make(chan empty),
+		entries:  make(map[string]*heapEntry),
+	}
+
+	workers := s.jobPool.size
+
+	s.wg.Add(1 + workers)
+	go d.loop()
+
+	for i := 0; i < workers; i++ {
+		go d.work()
+	}
+
+	return d
+}
+
+func (d *heapDispatcher) start(cfg jobCfg) {
+	startAt := d.s.startSlot(cfg)
+	d.do(func() { d.add(cfg, startAt) })
+}
+
+func (d *heapDispatcher) remove(name string) {
+	d.do(func() {
+		e, ok := d.entries[name]
+		if !ok {
+			return
+		}
+
+		e.removed = true
+		e.cancel()
+
+		if e.index >= 0 {
+			heap.Remove(&d.queue, e.index)
+		}
+
+		delete(d.entries, name)
+	})
+}
+
+func (d *heapDispatcher) reset(name string, prev time.Time) {
+	d.do(func() {
+		e, ok := d.entries[name]
+		if !ok || e.finished {
+			return
+		}
+
+		if e.busy {
+			e.resetFrom = prev
+			return
+		}
+
+		e.parked = false
+		d.schedule(e, prev, e.cfg)
+	})
+}
+
+func (d *heapDispatcher) resume(name string, catchUp bool) {
+	d.do(func() {
+		e, ok := d.entries[name]
+		if !ok || !e.parked {
+			// Nothing was missed while the job was paused, the entry is still queued
+			return
+		}
+
+		e.parked = false
+
+		if catchUp {
+			e.next = d.s.clock.Now()
+			d.push(e)
+			return
+		}
+
+		d.schedule(e, d.s.clock.Now(), e.cfg)
+	})
+}
+
+// do hands fn to the dispatcher goroutine without blocking
+func (d *heapDispatcher) do(fn func()) {
+	d.mu.Lock()
+	d.commands = append(d.commands, fn)
+	d.mu.Unlock()
+
+	select {
+	case d.wake <- empty{}:
+	default:
+	}
+}
+
+// loop is the dispatcher goroutine, it fires due entries and sleeps until the earliest next run or a command
+func (d *heapDispatcher) loop() {
+	defer d.s.wg.Done()
+	defer close(d.stopped)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		d.mu.Lock()
+		commands := d.commands
+		d.commands = nil
+		d.mu.Unlock()
+
+		for _, fn := range commands {
+			fn()
+		}
+
+		now := d.s.clock.Now()
+		for len(d.queue) > 0 && !d.queue[0].next.After(now) {
+			d.fire(heap.Pop(&d.queue).(*heapEntry))
+		}
+
+		d.acquire()
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+
+		var timerC <-chan time.Time
+		if wakeAt := d.wakeAt(); !wakeAt.IsZero() {
+			timer.Reset(timeUntilNextRun(wakeAt, d.s.clock.Now()))
+			timerC = timer.C
+		}
+
+		select {
+		case <-d.loopCtx.Done():
+			for name := range d.entries {
+				d.s.log.Info(d.ctx, "cronalt.Scheduler halted", KeyVal{"job", name})
+			}
+
+			for _, r := range d.waiting {
+				d.drop(r, d.loopCtx.Err())
+			}
+
+			d.waiting = nil
+
+			return
+		case <-timerC:
+		case <-d.wake:
+		case <-d.s.freed:
+		}
+	}
+}
+
+// add creates the entry of a job and queues its first run, startAt is the time of its run on start or zero
+func (d *heapDispatcher) add(cfg jobCfg, startAt time.Time) {
+	name := cfg.Job().Name()
+
+	ctx, cancel := context.WithCancel(d.loopCtx)
+	e := &heapEntry{
+		cfg:    cfg,
+		ctx:    ctx,
+		cancel: cancel,
+		index:  -1,
+	}
+	d.entries[name] = e
+
+	d.s.log.Info(d.ctx, "cronalt.Scheduler starting job", KeyVal{"job", name})
+
+	e.next, _ = d.s.firstRun(d.ctx, cfg, startAt)
+	if e.next.IsZero() {
+		e.finished = true
+		return
+	}
+
+	d.push(e)
+}
+
+// fire handles a due entry which was popped from the queue
+func (d *heapDispatcher) fire(e *heapEntry) {
+	cfg := e.cfg
+
+	if d.s.missedWhilePaused(d.ctx, cfg.Job().Name()) {
+		e.parked = true
+		return
+	}
+
+	fired(cfg.Timer(), e.next)
+
+	if !cfg.sequential() {
+		if atomic.LoadInt32(&e.running) >= int32(cfg.overlap.limit) {
+			d.s.skipped(d.ctx, cfg.Job().Name(), "", "previous run in progress")
+		} else {
+			atomic.AddInt32(&e.running, 1)
+			d.submit(e, func() { atomic.AddInt32(&e.running, -1) })
+		}
+
+		d.schedule(e, e.next, e.cfg)
+
+		return
+	}
+
+	e.busy = true
+	e.firedAt = d.s.clock.Now()
+	d.submit(e, func() {
+		d.do(func() { d.completed(e) })
+	})
+}
+
+// submit queues a run of an entry to wait for its slots, done is called once the run completes or is dropped
+func (d *heapDispatcher) submit(e *heapEntry, done func()) {
+	r := &heapRun{
+		e:        e,
+		runID:    d.s.queue(d.ctx, e.cfg),
+		queuedAt: d.s.clock.Now(),
+		done:     done,
+	}
+
+	if e.cfg.maxQueueWait > 0 {
+		r.deadline = r.queuedAt.Add(e.cfg.maxQueueWait)
+	}
+
+	d.waiting = append(d.waiting, r)
+}
+
+// acquire takes the slots of waiting runs by effective priority and hands the runs holding them to the workers
+// A run which cannot take its slots, e.g. because its group is busy, does not hold up the runs behind it
+// Runs of removed jobs and runs whose maximum queue wait elapsed are dropped
+func (d *heapDispatcher) acquire() {
+	if len(d.waiting) == 0 {
+		return
+	}
+
+	now := d.s.clock.Now()
+	pool := d.s.jobPool
+
+	sort.SliceStable(d.waiting, func(i, j int) bool {
+		a, b := d.waiting[i], d.waiting[j]
+		return pool.effective(a.e.cfg.priority, a.queuedAt, now) > pool.effective(b.e.cfg.priority, b.queuedAt, now)
+	})
+
+	waiting := d.waiting[:0]
+
+	for _, r := range d.waiting {
+		cfg := r.e.cfg
+
+		switch {
+		case r.e.ctx.Err() != nil:
+			d.drop(r, r.e.ctx.Err())
+		case d.s.tryAcquire(cfg.priority, cfg.groups):
+			d.acquired <- r
+		case !r.deadline.IsZero() && !now.Before(r.deadline):
+			d.drop(r, ErrPoolSaturated)
+		default:
+			waiting = append(waiting, r)
+		}
+	}
+
+	for i := len(waiting); i < len(d.waiting); i++ {
+		d.waiting[i] = nil
+	}
+
+	d.waiting = waiting
+}
+
+// drop reports a waiting run which is dropped because of err
+func (d *heapDispatcher) drop(r *heapRun, err error) {
+	d.s.dropQueued(d.ctx, r.e.cfg.Job().Name(), r.runID, err)
+	r.done()
+}
+
+// wakeAt returns the earliest of the next run and the deadlines of waiting runs, zero when there is none
+func (d *heapDispatcher) wakeAt() time.Time {
+	var at time.Time
+	if len(d.queue) > 0 {
+		at = d.queue[0].next
+	}
+
+	for _, r := range d.waiting {
+		if !r.deadline.IsZero() && (at.IsZero() || r.deadline.Before(at)) {
+			at = r.deadline
+		}
+	}
+
+	return at
+}
+
+// work is a worker goroutine, it runs the runs holding their slots one at a time until the dispatcher stopped
+func (d *heapDispatcher) work() {
+	defer d.s.wg.Done()
+
+	for {
+		select {
+		case r := <-d.acquired:
+			d.run(r)
+		case <-d.stopped:
+			// Drain the runs handed over before the dispatcher stopped, their entries are cancelled so they only release their slots
+			for {
+				select {
+				case r := <-d.acquired:
+					d.run(r)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// run runs a run holding its slots
+// A run handed over before its job was removed or the dispatcher stopped releases its slots and is dropped instead
+func (d *heapDispatcher) run(r *heapRun) {
+	defer r.done()
+
+	if err := r.e.ctx.Err(); err != nil {
+		d.s.release(r.e.cfg)
+		d.s.dropQueued(d.ctx, r.e.cfg.Job().Name(), r.runID, err)
+
+		return
+	}
+
+	_ = d.s.runAcquired(d.ctx, r.e.cfg, r.runID)
+}
+
+// completed queues the next run of an entry once its sequential run completed
+func (d *heapDispatcher) completed(e *heapEntry) {
+	e.busy = false
+
+	if e.removed {
+		return
+	}
+
+	if !e.resetFrom.IsZero() {
+		d.schedule(e, e.resetFrom, e.cfg)
+		e.resetFrom = time.Time{}
+
+		return
+	}
+
+	// The next run is computed from the scheduled time of this run rather than the time it fired,
+	// or from the time it completed with WithFixedDelay
+	prev := e.next
+	if e.cfg.fixedDelay {
+		prev = d.s.clock.Now()
+	}
+
+	d.schedule(e, prev, e.cfg.ranAt(e.firedAt))
+}
+
+// schedule computes the next run of an entry from prev with cfg and queues it unless its timer has no further runs
+func (d *heapDispatcher) schedule(e *heapEntry, prev time.Time, cfg jobCfg) {
+	e.next, _ = d.s.getTimeUntilNextRun(d.ctx, prev, cfg)
+	if e.next.IsZero() {
+		e.finished = true
+
+		if e.index >= 0 {
+			heap.Remove(&d.queue, e.index)
+		}
+
+		return
+	}
+
+	d.push(e)
+}
+
+// push adds an entry to the queue or moves it if it is already queued
+func (d *heapDispatcher) push(e *heapEntry) {
+	if e.index >= 0 {
+		heap.Fix(&d.queue, e.index)
+		return
+	}
+
+	heap.Push(&d.queue, e)
+}
+
+// entryQueue is a min-heap of entries ordered by their next run
+type entryQueue []*heapEntry
+
+var _ heap.Interface = (*entryQueue)(nil)
+
+func (q entryQueue) Len() int {
+	return len(q)
+}
+
+func (q entryQueue) Less(i, j int) bool {
+	return q[i].next.Before(q[j].next)
+}
+
+func (q entryQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *entryQueue) Push(x interface{}) {
+	e := x.(*heapEntry)
+	e.index = len(*q)
+	*q = append(*q, e)
+}
+
+func (q *entryQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	e.index = -1
+	*q = old[:n-1]
+
+	return e
+}
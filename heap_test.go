@@ -0,0 +1,278 @@
+package cronalt
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ahmedalhulaibi/cronalt/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_HeapDispatcher(t *testing.T) {
+	t.Run("Should start, pause, resume and remove jobs", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ran := make(chan string)
+
+		s, err := NewScheduler(10, WithHeapDispatcher())
+		require.NoError(t, err)
+		require.NoError(t, s.Schedule(Every(time.Millisecond), signalJob{name: "first", ran: ran}))
+		require.NoError(t, s.Schedule(Every(time.Hour), signalJob{name: "hourly", ran: ran}))
+
+		done := make(chan empty)
+		go func() {
+			s.Start(ctx)
+			close(done)
+		}()
+
+		require.Equal(t, "first", <-ran)
+
+		require.NoError(t, s.Pause("first"))
+		require.NoError(t, s.Schedule(Every(time.Millisecond), signalJob{name: "second", ran: ran}))
+
+		// Drain any run of first which was in-flight while it was paused
+		for name := range ran {
+			if name == "second" {
+				break
+			}
+		}
+
+		require.NoError(t, s.Remove("second"))
+		require.NoError(t, s.Resume("first", ResumeCatchUp()))
+
+		for name := range ran {
+			if name == "first" {
+				break
+			}
+		}
+
+		require.NoError(t, s.Trigger(ctx, "hourly", TriggerResetTimer()))
+
+		for name := range ran {
+			if name == "hourly" {
+				break
+			}
+		}
+
+		cancel()
+		<-done
+	})
+	t.Run("Should never run a job concurrently with OverlapSkip", func(t *testing.T) {
+		var running, peak int32
+		cj := concurrencyJob{name: "concurrency", running: &running, peak: &peak, runs: make(chan empty)}
+
+		s, err := NewScheduler(10, WithHeapDispatcher())
+		require.NoError(t, err)
+		require.NoError(t, s.Schedule(Every(time.Millisecond), cj, WithOverlapPolicy(OverlapSkip)))
+
+		done := startScheduler(t, s)
+
+		for i := 0; i < 5; i++ {
+			<-cj.runs
+		}
+
+		require.NoError(t, s.Shutdown(context.Background()))
+		<-done
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&peak))
+	})
+	t.Run("Should run due jobs on maxConcurrentJobs workers", func(t *testing.T) {
+		var count int64
+		done := make(chan empty)
+
+		s, err := NewScheduler(2, WithHeapDispatcher())
+		require.NoError(t, err)
+
+		for i := 0; i < 2000; i++ {
+			j := countingJob{name: fmt.Sprintf("job-%d", i), count: &count, done: done, total: 2000}
+			require.NoError(t, s.Schedule(Every(time.Millisecond), j))
+		}
+
+		before := runtime.NumGoroutine()
+		started := startScheduler(t, s)
+		<-done
+
+		// The dispatcher, its two workers and the goroutine running Start
+		assert.LessOrEqual(t, runtime.NumGoroutine()-before, 4)
+
+		require.NoError(t, s.Shutdown(context.Background()))
+		<-started
+	})
+	t.Run("Should not start queued runs after Shutdown", func(t *testing.T) {
+		var count int64
+
+		s, err := NewScheduler(1, WithHeapDispatcher())
+		require.NoError(t, err)
+
+		bj := blockingJob{name: "blocker", started: make(chan empty, 1), release: make(chan empty)}
+		require.NoError(t, s.Schedule(Every(time.Millisecond), bj))
+
+		for i := 0; i < 100; i++ {
+			j := countingJob{name: fmt.Sprintf("job-%d", i), count: &count, done: make(chan empty), total: -1}
+			require.NoError(t, s.Schedule(Every(time.Millisecond), j))
+		}
+
+		started := startScheduler(t, s)
+		<-bj.started
+
+		shutdown := make(chan error)
+		go func() {
+			shutdown <- s.Shutdown(context.Background())
+		}()
+
+		require.Eventually(t, func() bool {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			return s.ctx == nil
+		}, time.Second, time.Millisecond)
+
+		ran := atomic.LoadInt64(&count)
+		close(bj.release)
+
+		require.NoError(t, <-shutdown)
+		<-started
+
+		assert.Equal(t, ran, atomic.LoadInt64(&count))
+	})
+}
+
+func Test_entryQueue(t *testing.T) {
+	nowFixture := time.Date(2021, 01, 01, 01, 01, 01, 01, time.UTC)
+
+	d := &heapDispatcher{}
+	for _, offset := range []int{5, 1, 4, 2, 3} {
+		d.push(&heapEntry{next: nowFixture.Add(time.Duration(offset) * time.Second), index: -1})
+	}
+
+	for i := 1; i <= 5; i++ {
+		e := d.queue[0]
+		assert.Equal(t, nowFixture.Add(time.Duration(i)*time.Second), e.next)
+
+		d.queue[0].next = d.queue[0].next.Add(time.Hour)
+		d.push(e)
+	}
+}
+
+type countingJob struct {
+	name  string
+	count *int64
+	done  chan empty
+	total int64
+}
+
+func (cj countingJob) Name() string {
+	return cj.name
+}
+
+func (cj countingJob) Runner() JobFn {
+	return func(ctx context.Context) error {
+		if atomic.AddInt64(cj.count, 1) == cj.total {
+			close(cj.done)
+		}
+
+		return nil
+	}
+}
+
+// benchmarkDispatch schedules jobs due every millisecond and measures how long it takes to dispatch b.N runs
+func benchmarkDispatch(b *testing.B, jobs int, opts ...SchedulerOption) {
+	var count int64
+	done := make(chan empty)
+
+	s, err := NewScheduler(64, opts...)
+	require.NoError(b, err)
+
+	for i := 0; i < jobs; i++ {
+		j := countingJob{name: fmt.Sprintf("job-%d", i), count: &count, done: done, total: int64(b.N)}
+		require.NoError(b, s.Schedule(Every(time.Millisecond), j, WithMisfirePolicy(MisfireSkip)))
+	}
+
+	b.ResetTimer()
+
+	go s.Start(context.Background())
+	<-done
+
+	b.StopTimer()
+	require.NoError(b, s.Shutdown(context.Background()))
+}
+
+// benchmarkIdle measures how long it takes to start and shut down a Scheduler with idle jobs
+func benchmarkIdle(b *testing.B, jobs int, opts ...SchedulerOption) {
+	for n := 0; n < b.N; n++ {
+		var computed int64
+		ready := make(chan empty)
+
+		l := &nextRunListener{computed: &computed, ready: ready, total: int64(jobs)}
+
+		s, err := NewScheduler(64, append(opts, WithListener(l))...)
+		require.NoError(b, err)
+
+		for i := 0; i < jobs; i++ {
+			require.NoError(b, s.Schedule(Every(time.Hour), job.Decorate(errJob{}, renamed(fmt.Sprintf("job-%d", i)))))
+		}
+
+		go s.Start(context.Background())
+		<-ready
+
+		require.NoError(b, s.Shutdown(context.Background()))
+	}
+}
+
+type nextRunListener struct {
+	NoopListener
+	computed *int64
+	ready    chan empty
+	total    int64
+}
+
+func (l *nextRunListener) OnNextRunComputed(_ context.Context, _ Event) {
+	if atomic.AddInt64(l.computed, 1) == l.total {
+		close(l.ready)
+	}
+}
+
+type renamedJob struct {
+	job.Job
+	name string
+}
+
+func (r renamedJob) Name() string {
+	return r.name
+}
+
+func renamed(name string) job.Decorator {
+	return func(j job.Job) job.Job {
+		return renamedJob{Job: j, name: name}
+	}
+}
+
+func BenchmarkScheduler_Dispatch(b *testing.B) {
+	for _, jobs := range []int{100, 10000} {
+		b.Run(fmt.Sprintf("loop/jobs=%d", jobs), func(b *testing.B) {
+			benchmarkDispatch(b, jobs)
+		})
+		b.Run(fmt.Sprintf("heap/jobs=%d", jobs), func(b *testing.B) {
+			benchmarkDispatch(b, jobs, WithHeapDispatcher())
+		})
+	}
+}
+
+func BenchmarkScheduler_Idle(b *testing.B) {
+	for _, jobs := range []int{100, 10000} {
+		b.Run(fmt.Sprintf("loop/jobs=%d", jobs), func(b *testing.B) {
+			b.ReportAllocs()
+			benchmarkIdle(b, jobs)
+		})
+		b.Run(fmt.Sprintf("heap/jobs=%d", jobs), func(b *testing.B) {
+			b.ReportAllocs()
+			benchmarkIdle(b, jobs, WithHeapDispatcher())
+		})
+	}
+}
@@ -5,9 +5,9 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/ahmedalhulaibi/cronalt/cron"
 	"github.com/ahmedalhulaibi/cronalt/job"
 	"github.com/ahmedalhulaibi/loggy"
-	"github.com/gorhill/cronexpr"
 	"go.uber.org/zap"
 
 	"github.com/ahmedalhulaibi/cronalt"
@@ -21,8 +21,13 @@ func main() {
 
 	scheduler, _ := cronalt.NewScheduler(10, cronalt.WithLogger(loggylog))
 
+	everyMinute, err := cron.Parse("* * * * *")
+	if err != nil {
+		panic(err)
+	}
+
 	scheduler.Schedule(cronalt.Every(time.Second), echoJob{})
-	scheduler.Schedule(cronexpr.MustParse("0 * * * * * *"), foo{})
+	scheduler.Schedule(everyMinute, foo{})
 	scheduler.Schedule(cronalt.Every(5*time.Second), panicker{})
 
 	scheduler.Start(context.Background())
@@ -3,12 +3,11 @@ package main
 import (
 	"context"
 	"fmt"
-	"sync"
 	"time"
 
+	"github.com/ahmedalhulaibi/cronalt/cron"
 	"github.com/ahmedalhulaibi/cronalt/job"
 	"github.com/ahmedalhulaibi/loggy"
-	"github.com/gorhill/cronexpr"
 	"go.uber.org/zap"
 
 	"github.com/ahmedalhulaibi/cronalt"
@@ -20,20 +19,15 @@ func main() {
 	loggylog := localLogger{loggy.New(logger.Sugar())}
 	defer loggylog.Sync()
 
-	ctx := context.Background()
+	scheduler, _ := cronalt.NewScheduler(10, cronalt.WithLogger(loggylog))
 
-	js := job.NewStore()
-
-	scheduler, _ := cronalt.NewScheduler(10, cronalt.WithJobStore(js), cronalt.WithLogger(loggylog))
-	dscheduler := &DynamicJobScheduler{
-		ctx:       ctx,
-		state:     stopped,
-		scheduler: scheduler,
-		jobStore:  js,
+	everyMinute, err := cron.Parse("* * * * *")
+	if err != nil {
+		panic(err)
 	}
 
-	dscheduler.Schedule(cronexpr.MustParse("0 * * * * * *"), foo{})
-	dscheduler.Schedule(cronalt.Every(5*time.Second), panicker{})
+	scheduler.Schedule(everyMinute, foo{})
+	scheduler.Schedule(cronalt.Every(5*time.Second), panicker{})
 
 	go func() {
 		var flip bool
@@ -41,81 +35,16 @@ func main() {
 		flipjob := echoJob{}
 		for range ticker.C {
 			if flip {
-				dscheduler.Schedule(cronalt.Every(time.Second), flipjob)
+				scheduler.Schedule(cronalt.Every(time.Second), flipjob)
 			} else {
-				dscheduler.Remove(flipjob)
+				scheduler.Remove(flipjob.Name())
 			}
 
 			flip = !flip
 		}
 	}()
 
-	dscheduler.Wait(ctx)
-}
-
-type jobStore interface {
-	Remove(name string) error
-}
-
-type schedulerState byte
-
-const (
-	stopped schedulerState = iota
-	started
-)
-
-type DynamicJobScheduler struct {
-	ctx        context.Context
-	cancelFunc context.CancelFunc
-	scheduler  *cronalt.Scheduler
-	jobStore   jobStore
-	state      schedulerState
-	locker     sync.Mutex
-}
-
-func (d *DynamicJobScheduler) Schedule(jt job.Timer, j job.Job) error {
-	d.locker.Lock()
-	defer d.locker.Unlock()
-
-	err := d.scheduler.Schedule(jt, j)
-	if err != nil {
-		return err
-	}
-
-	d.restart()
-
-	return nil
-}
-
-func (d *DynamicJobScheduler) Remove(j job.Job) error {
-	d.locker.Lock()
-	defer d.locker.Unlock()
-
-	err := d.jobStore.Remove(j.Name())
-	if err != nil {
-		return err
-	}
-
-	d.restart()
-
-	return nil
-}
-
-func (d *DynamicJobScheduler) restart() {
-	if d.state == started {
-		d.cancelFunc()
-		d.state = stopped
-	}
-
-	ctx, cancelFunc := context.WithCancel(d.ctx)
-	d.cancelFunc = cancelFunc
-
-	go d.scheduler.Start(ctx)
-	d.state = started
-}
-
-func (d *DynamicJobScheduler) Wait(ctx context.Context) {
-	<-ctx.Done()
+	scheduler.Start(context.Background())
 }
 
 type localLogger struct {
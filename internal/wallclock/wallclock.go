@@ -0,0 +1,103 @@
+// Package wallclock evaluates timers on the wall clock of a location
+//
+// A wall clock time is represented as a time.Time in UTC holding the fields read off the clock of the location.
+// Timers such as cron expressions compute their next run on these times, which are then mapped back to instants
+// with defined behavior around daylight saving time transitions:
+//   - a wall clock time skipped by a gap (e.g. 02:30 when clocks jump from 02:00 to 03:00) fires at the end of the gap
+//   - a wall clock time repeated by an overlap (e.g. 01:30 when clocks fall back from 02:00 to 01:00) fires once,
+//     at its first occurrence after the previous run
+package wallclock
+
+import (
+	"time"
+
+	"github.com/ahmedalhulaibi/cronalt/job"
+)
+
+// maxSteps bounds how many wall clock times are tried when they all map to instants before the previous run
+const maxSteps = 8
+
+type timer struct {
+	inner job.Timer
+	loc   *time.Location
+}
+
+// In returns a job.Timer which evaluates t on the wall clock of loc
+func In(t job.Timer, loc *time.Location) job.Timer {
+	return timer{inner: t, loc: loc}
+}
+
+func (t timer) Next(prevStart time.Time) time.Time {
+	w := ToWall(prevStart, t.loc)
+
+	for i := 0; i < maxSteps; i++ {
+		w = t.inner.Next(w)
+		if w.IsZero() {
+			return time.Time{}
+		}
+
+		if next, ok := FromWall(w, t.loc, prevStart); ok {
+			return next
+		}
+	}
+
+	return time.Time{}
+}
+
+// ToWall returns the wall clock time of t in loc
+func ToWall(t time.Time, loc *time.Location) time.Time {
+	l := t.In(loc)
+
+	return time.Date(l.Year(), l.Month(), l.Day(), l.Hour(), l.Minute(), l.Second(), l.Nanosecond(), time.UTC)
+}
+
+// FromWall returns the first instant after the instant after which the clock of loc reads w
+// A wall clock time skipped by a gap maps to the end of the gap
+// ok is false when every instant reading w is at or before after
+func FromWall(w time.Time, loc *time.Location, after time.Time) (t time.Time, ok bool) {
+	// The clock reads w at w minus the offset in effect at that instant, which is one of the offsets
+	// in effect shortly before or after w since transitions are far apart
+	_, before := w.Add(-24 * time.Hour).In(loc).Zone()
+	_, later := w.Add(24 * time.Hour).In(loc).Zone()
+
+	var exists, found bool
+
+	for _, offset := range []int{before, later} {
+		c := w.Add(-time.Duration(offset) * time.Second)
+		if !ToWall(c, loc).Equal(w) {
+			continue
+		}
+
+		exists = true
+
+		if c.After(after) && (!found || c.Before(t)) {
+			t, found = c, true
+		}
+	}
+
+	if exists {
+		return t.In(loc), found
+	}
+
+	// w falls in a gap, fire when the gap ends
+	t = gapEnd(w.Add(-time.Duration(later)*time.Second), w.Add(-time.Duration(before)*time.Second), loc)
+
+	return t.In(loc), t.After(after)
+}
+
+// gapEnd returns the instant of the transition between lo and hi, the first instant whose offset is the one at hi
+func gapEnd(lo, hi time.Time, loc *time.Location) time.Time {
+	_, target := hi.In(loc).Zone()
+
+	for hi.Sub(lo) > time.Second {
+		mid := lo.Add(hi.Sub(lo) / 2)
+
+		if _, offset := mid.In(loc).Zone(); offset == target {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+
+	return hi.Truncate(time.Second)
+}
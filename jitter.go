@@ -0,0 +1,176 @@
+package cronalt
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ahmedalhulaibi/cronalt/job"
+)
+
+type jitterCfg struct {
+	max       time.Duration
+	symmetric bool
+	byName    bool
+	rng       *lockedRand
+}
+
+// JitterOption configures a timer returned by Jitter
+type JitterOption func(cfg jitterCfg) jitterCfg
+
+// JitterSymmetric returns a JitterOption to shift runs earlier or later by up to max, default only shifts runs later
+func JitterSymmetric() JitterOption {
+	return func(cfg jitterCfg) jitterCfg {
+		cfg.symmetric = true
+		return cfg
+	}
+}
+
+// JitterByName returns a JitterOption to derive the shift of each run from the job name and its scheduled time
+// instead of at random, so replicas of a job agree on when it runs
+func JitterByName() JitterOption {
+	return func(cfg jitterCfg) jitterCfg {
+		cfg.byName = true
+		return cfg
+	}
+}
+
+// JitterRand returns a JitterOption to draw random shifts from r, e.g. a rand.Rand with a fixed seed in tests
+func JitterRand(r *rand.Rand) JitterOption {
+	return func(cfg jitterCfg) jitterCfg {
+		cfg.rng = &lockedRand{r: r}
+		return cfg
+	}
+}
+
+// lockedRand guards a rand.Rand shared by the timers bound from the same Jitter timer
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func (l *lockedRand) int63n(n int64) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.r.Int63n(n)
+}
+
+type jitterTimer struct {
+	inner job.Timer
+	cfg   jitterCfg
+	// key is the name of the job the timer is bound to
+	key string
+
+	mu sync.Mutex
+	// last is the previous time returned by Next and base the time of inner it was shifted from
+	// so the inner timer continues from its own schedule rather than drifting with the shifts
+	last, base time.Time
+}
+
+var (
+	_ jobTimer         = (*jitterTimer)(nil)
+	_ job.Binder       = (*jitterTimer)(nil)
+	_ job.FireObserver = (*jitterTimer)(nil)
+	_ validator        = (*jitterTimer)(nil)
+)
+
+// Jitter returns a job.Timer which shifts each run of t later by a random duration of up to max
+// Shifts are not carried over, each run is shifted from the time scheduled by t, which should be further apart than max
+func Jitter(t job.Timer, max time.Duration, opts ...JitterOption) job.Timer {
+	cfg := jitterCfg{max: max}
+
+	for _, opt := range opts {
+		cfg = opt(cfg)
+	}
+
+	return &jitterTimer{inner: t, cfg: cfg}
+}
+
+// Bind returns a timer for j with its own state, binding t to j as well
+func (t *jitterTimer) Bind(j job.Job) job.Timer {
+	inner := t.inner
+	if b, ok := inner.(job.Binder); ok {
+		inner = b.Bind(j)
+	}
+
+	return &jitterTimer{inner: inner, cfg: t.cfg, key: j.Name()}
+}
+
+// Err returns the error of the wrapped timer when it is built in steps, e.g. DayTimer
+func (t *jitterTimer) Err() error {
+	return timersErr(t.inner)
+}
+
+func (t *jitterTimer) Next(prevStart time.Time) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	prev := prevStart
+	if !t.last.IsZero() && prevStart.Equal(t.last) {
+		prev = t.base
+	}
+
+	base := t.inner.Next(prev)
+	if base.IsZero() {
+		return base
+	}
+
+	next := base.Add(t.offset(base))
+	if !next.After(prevStart) {
+		next = base
+	}
+
+	t.last, t.base = next, base
+
+	return next
+}
+
+// Fired passes a run of the job on to t at the time t scheduled it before it was shifted
+func (t *jitterTimer) Fired(at time.Time) {
+	t.mu.Lock()
+	if !t.last.IsZero() && at.Equal(t.last) {
+		at = t.base
+	}
+	t.mu.Unlock()
+
+	fired(t.inner, at)
+}
+
+// offset returns the shift of the run scheduled at base
+func (t *jitterTimer) offset(base time.Time) time.Duration {
+	if t.cfg.max <= 0 {
+		return 0
+	}
+
+	span := int64(t.cfg.max) + 1
+	if t.cfg.symmetric {
+		span += int64(t.cfg.max)
+	}
+
+	var n int64
+
+	switch {
+	case t.cfg.byName:
+		h := fnv.New64a()
+		h.Write([]byte(t.key))
+
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], uint64(base.UnixNano()))
+		h.Write(b[:])
+
+		n = int64(h.Sum64() % uint64(span))
+	case t.cfg.rng != nil:
+		n = t.cfg.rng.int63n(span)
+	default:
+		n = rand.Int63n(span)
+	}
+
+	if t.cfg.symmetric {
+		n -= int64(t.cfg.max)
+	}
+
+	return time.Duration(n)
+}
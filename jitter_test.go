@@ -0,0 +1,140 @@
+package cronalt
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/ahmedalhulaibi/cronalt/cron"
+	"github.com/ahmedalhulaibi/cronalt/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJitter(t *testing.T) {
+	startFixture := time.Date(2021, 01, 01, 0, 0, 0, 0, time.UTC)
+	everyMinute, err := cron.Parse("* * * * *")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		timer  func() *jitterTimer
+		minOff time.Duration
+		maxOff time.Duration
+	}{
+		"Should shift runs later by up to max": {
+			timer: func() *jitterTimer {
+				return Jitter(Every(time.Minute), 10*time.Second, JitterRand(rand.New(rand.NewSource(1)))).(*jitterTimer)
+			},
+			minOff: 0,
+			maxOff: 10 * time.Second,
+		},
+		"Should shift runs earlier or later by up to max": {
+			timer: func() *jitterTimer {
+				return Jitter(everyMinute, 10*time.Second, JitterSymmetric(), JitterRand(rand.New(rand.NewSource(1)))).(*jitterTimer)
+			},
+			minOff: -10 * time.Second,
+			maxOff: 10 * time.Second,
+		},
+		"Should shift runs by the job name": {
+			timer: func() *jitterTimer {
+				return Jitter(everyMinute, 10*time.Second, JitterSymmetric(), JitterByName()).(*jitterTimer).Bind(signalJob{name: "report"}).(*jitterTimer)
+			},
+			minOff: -10 * time.Second,
+			maxOff: 10 * time.Second,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			timer := tt.timer()
+
+			var shifted bool
+
+			prev := startFixture
+			for i := 1; i <= 100; i++ {
+				next := timer.Next(prev)
+				off := next.Sub(startFixture.Add(time.Duration(i) * time.Minute))
+
+				require.True(t, off >= tt.minOff && off <= tt.maxOff, "run %d shifted by %s", i, off)
+				require.True(t, next.After(prev))
+
+				shifted = shifted || off != 0
+				prev = next
+			}
+
+			assert.True(t, shifted)
+		})
+	}
+}
+
+func TestJitter_Deterministic(t *testing.T) {
+	startFixture := time.Date(2021, 01, 01, 0, 0, 0, 0, time.UTC)
+
+	runs := func(timer func() job.Timer) []time.Time {
+		tm := timer()
+
+		var out []time.Time
+
+		prev := startFixture
+		for i := 0; i < 10; i++ {
+			prev = tm.Next(prev)
+			out = append(out, prev)
+		}
+
+		return out
+	}
+
+	t.Run("Should repeat runs with the same seed", func(t *testing.T) {
+		t.Parallel()
+
+		seeded := func() job.Timer {
+			return Jitter(Every(time.Minute), time.Minute, JitterRand(rand.New(rand.NewSource(42))))
+		}
+
+		assert.Equal(t, runs(seeded), runs(seeded))
+	})
+
+	t.Run("Should repeat runs for the same job name", func(t *testing.T) {
+		t.Parallel()
+
+		byName := func(name string) func() job.Timer {
+			return func() job.Timer {
+				return Jitter(Every(time.Minute), time.Minute, JitterByName()).(*jitterTimer).Bind(signalJob{name: name})
+			}
+		}
+
+		assert.Equal(t, runs(byName("report")), runs(byName("report")))
+
+		distinct := map[time.Time]bool{}
+		for i := 0; i < 10; i++ {
+			distinct[runs(byName(fmt.Sprintf("job-%d", i)))[0]] = true
+		}
+
+		assert.Greater(t, len(distinct), 1)
+	})
+
+	t.Run("Should bind the inner timer", func(t *testing.T) {
+		t.Parallel()
+
+		hashed, err := cron.Parse("H * * * *")
+		require.NoError(t, err)
+
+		j := signalJob{name: "report"}
+		timer := Jitter(hashed, 0).(*jitterTimer).Bind(j)
+
+		assert.Equal(t, hashed.Bind(j).Next(startFixture), timer.Next(startFixture))
+	})
+}
+
+func TestJitter_Err(t *testing.T) {
+	s, err := NewScheduler(1)
+	require.NoError(t, err)
+
+	err = s.Schedule(Jitter(Daily().At("25:00"), time.Second), signalJob{name: "invalid"})
+	assert.True(t, errors.Is(err, ErrInvalidDayTimer))
+}
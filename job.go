@@ -1,6 +1,11 @@
 package cronalt
 
-import "github.com/ahmedalhulaibi/cronalt/job"
+import (
+	"sort"
+	"time"
+
+	"github.com/ahmedalhulaibi/cronalt/job"
+)
 
 type jobStore interface {
 	Add(j job.Config) error
@@ -12,6 +17,79 @@ type jobStore interface {
 type jobCfg struct {
 	timer job.Timer
 	j     job.Job
+
+	// maxQueueWait bounds how long a run waits for a slot in the job pool, zero waits indefinitely
+	maxQueueWait time.Duration
+	// misfire resolves scheduled times which passed before they could fire, nil uses MisfireFireOnce
+	misfire MisfirePolicy
+	// overlap decides what happens to runs which become due while a previous run is in progress
+	overlap OverlapPolicy
+	// lastRun seeds the first scheduled time of the job, zero uses the time the job loop starts
+	lastRun time.Time
+	// fixedDelay computes the next run from the time the previous run completed instead of the time it was scheduled
+	fixedDelay bool
+	// runOnStart runs the job once as soon as it starts, nil uses the default of the Scheduler
+	runOnStart *bool
+	// timeout bounds how long a run takes and abandonAfter how long a run is waited for past it, zero uses the default of the Scheduler
+	timeout      time.Duration
+	abandonAfter time.Duration
+	// priority is the class of the job's runs waiting for a slot in the job pool
+	priority Priority
+	// groups are the sorted names of the concurrency groups the job belongs to
+	groups []string
+	// metadata describes the job, see Scheduler.Select
+	metadata job.Metadata
+}
+
+func (j jobCfg) misfirePolicy() MisfirePolicy {
+	if j.misfire == nil {
+		return MisfireFireOnce
+	}
+
+	return j.misfire
+}
+
+// ranAt returns the job's config to compute the run following its sequential run fired at firedAt
+// OverlapQueue leaves at most one run pending, occurrences which became due while the run was in progress fire once
+// whatever the job's MisfirePolicy, which still resolves occurrences missed before it fired
+func (j jobCfg) ranAt(firedAt time.Time) jobCfg {
+	if !j.overlap.sequential() {
+		return j
+	}
+
+	misfire := j.misfirePolicy()
+	j.misfire = func(missed, now time.Time, t job.Timer) time.Time {
+		if missed.After(firedAt) {
+			return MisfireFireOnce(missed, now, t)
+		}
+
+		return misfire(missed, now, t)
+	}
+
+	return j
+}
+
+// sequential reports whether runs are executed one after the other
+func (j jobCfg) sequential() bool {
+	return j.fixedDelay || j.overlap.sequential()
+}
+
+// runsOnStart reports whether the job runs once as soon as it starts given the default of the Scheduler
+func (j jobCfg) runsOnStart(byDefault bool) bool {
+	if j.runOnStart == nil {
+		return byDefault
+	}
+
+	return *j.runOnStart
+}
+
+// configOf returns the jobCfg of a job.Config with default options when it was not registered through Schedule
+func configOf(c job.Config) jobCfg {
+	if cfg, ok := c.(jobCfg); ok {
+		return cfg
+	}
+
+	return jobCfg{timer: c.Timer(), j: c.Job()}
 }
 
 func (j jobCfg) Job() job.Job {
@@ -21,3 +99,158 @@ func (j jobCfg) Job() job.Job {
 func (j jobCfg) Timer() job.Timer {
 	return j.timer
 }
+
+// Metadata implements job.Describer so the job store can select jobs by their Metadata
+func (j jobCfg) Metadata() job.Metadata {
+	return j.metadata
+}
+
+// JobOption configures how the Scheduler runs a single job
+type JobOption func(cfg jobCfg) jobCfg
+
+// WithMaxQueueWait returns a JobOption to bound how long a run waits for a slot in the job pool
+// When d elapses the run is dropped and reported as skipped because the pool is saturated
+func WithMaxQueueWait(d time.Duration) JobOption {
+	return func(cfg jobCfg) jobCfg {
+		cfg.maxQueueWait = d
+		return cfg
+	}
+}
+
+// WithMisfirePolicy returns a JobOption to choose how runs missed while the pool was busy or the process was down are handled
+// Default is MisfireFireOnce
+func WithMisfirePolicy(p MisfirePolicy) JobOption {
+	return func(cfg jobCfg) jobCfg {
+		cfg.misfire = p
+		return cfg
+	}
+}
+
+// WithLastRun returns a JobOption to schedule the first run of the job relative to the time it last ran
+// e.g. a time loaded from persistent storage, so runs missed while the process was down go through the MisfirePolicy
+func WithLastRun(t time.Time) JobOption {
+	return func(cfg jobCfg) jobCfg {
+		cfg.lastRun = t
+		return cfg
+	}
+}
+
+// WithOverlapPolicy returns a JobOption to choose what happens when the job is due while a previous run is in progress
+// Default is OverlapQueue
+func WithOverlapPolicy(p OverlapPolicy) JobOption {
+	return func(cfg jobCfg) jobCfg {
+		cfg.overlap = p
+		return cfg
+	}
+}
+
+// WithFixedDelay returns a JobOption to compute the next run from the time the previous run completed
+// instead of the time it was scheduled, e.g. with Every(time.Minute) a run starts a minute after the previous one ends
+// Runs of the job are sequential regardless of its OverlapPolicy
+func WithFixedDelay() JobOption {
+	return func(cfg jobCfg) jobCfg {
+		cfg.fixedDelay = true
+		return cfg
+	}
+}
+
+// WithRunOnStart returns a JobOption to run the job once as soon as it starts instead of waiting for its timer
+// The following runs are scheduled from the time of that run, e.g. a day later with Every(24*time.Hour)
+// Passing false opts the job out of the default set with WithRunOnStartDefault
+func WithRunOnStart(run bool) JobOption {
+	return func(cfg jobCfg) jobCfg {
+		cfg.runOnStart = &run
+		return cfg
+	}
+}
+
+// WithTimeout returns a JobOption to bound how long each run of the job takes
+// The context of a run is cancelled after d and a run which outlives it is reported as timed out with an error wrapping ErrJobTimedOut
+// A negative d does not bound runs regardless of WithDefaultTimeout
+func WithTimeout(d time.Duration) JobOption {
+	return func(cfg jobCfg) jobCfg {
+		cfg.timeout = d
+		return cfg
+	}
+}
+
+// WithAbandonAfter returns a JobOption to abandon a run which is still running grace after its timeout, see WithTimeout
+// An abandoned run is reported as timed out with an error wrapping ErrJobAbandoned and frees its slot in the job pool
+// while the job keeps running in the background, use it for jobs which may ignore the cancellation of their context
+// A negative grace never abandons runs regardless of WithDefaultAbandonAfter
+func WithAbandonAfter(grace time.Duration) JobOption {
+	return func(cfg jobCfg) jobCfg {
+		cfg.abandonAfter = grace
+		return cfg
+	}
+}
+
+// WithPriority returns a JobOption to set the class of the job's runs waiting for a slot in the job pool
+// Free slots are granted to higher classes first, default is PriorityNormal
+func WithPriority(p Priority) JobOption {
+	return func(cfg jobCfg) jobCfg {
+		cfg.priority = p
+		return cfg
+	}
+}
+
+// WithGroups returns a JobOption to add the job to named concurrency groups registered with WithConcurrencyGroup
+// A run waits for a slot in every group before waiting for a slot in the job pool, Schedule returns an error wrapping
+// ErrUnknownGroup for a group which is not registered
+func WithGroups(names ...string) JobOption {
+	return func(cfg jobCfg) jobCfg {
+		seen := make(map[string]bool, len(cfg.groups)+len(names))
+		groups := make([]string, 0, len(cfg.groups)+len(names))
+
+		for _, name := range append(append([]string{}, cfg.groups...), names...) {
+			if !seen[name] {
+				seen[name] = true
+				groups = append(groups, name)
+			}
+		}
+
+		// Groups are always acquired in the same order so runs sharing several groups cannot deadlock
+		sort.Strings(groups)
+		cfg.groups = groups
+
+		return cfg
+	}
+}
+
+// WithMetadata returns a JobOption to describe the job with tags, an owner, a description and labels
+// Passing it several times adds up the tags and labels, later owners and descriptions win
+// Jobs can be looked up and managed together by their Metadata, see Scheduler.Select
+func WithMetadata(m job.Metadata) JobOption {
+	return func(cfg jobCfg) jobCfg {
+		tags := append(append([]string{}, cfg.metadata.Tags...), m.Tags...)
+
+		labels := make(map[string]string, len(cfg.metadata.Labels)+len(m.Labels))
+		for k, v := range cfg.metadata.Labels {
+			labels[k] = v
+		}
+
+		for k, v := range m.Labels {
+			labels[k] = v
+		}
+
+		cfg.metadata.Tags, cfg.metadata.Labels = tags, labels
+
+		if m.Owner != "" {
+			cfg.metadata.Owner = m.Owner
+		}
+
+		if m.Description != "" {
+			cfg.metadata.Description = m.Description
+		}
+
+		return cfg
+	}
+}
+
+// WithTags returns a JobOption to add tags to the Metadata of the job, see WithMetadata
+func WithTags(tags ...string) JobOption {
+	return func(cfg jobCfg) jobCfg {
+		cfg.metadata.Tags = append(append([]string{}, cfg.metadata.Tags...), tags...)
+		return cfg
+	}
+}
@@ -17,6 +17,18 @@ type Timer interface {
 	Next(prevStart time.Time) time.Time
 }
 
+// Binder is implemented by a Timer whose schedule depends on the job it runs, e.g. a cron expression with hashed fields
+// The Scheduler binds the Timer to its Job when the job is scheduled
+type Binder interface {
+	Bind(j Job) Timer
+}
+
+// FireObserver is implemented by a Timer which keeps track of the runs of its job, e.g. to stop after a number of runs
+// The Scheduler calls Fired with the scheduled time of each run as it fires, before asking the Timer for the next run
+type FireObserver interface {
+	Fired(at time.Time)
+}
+
 type Job interface {
 	Name() string
 	Runner() JobFn
@@ -75,3 +75,19 @@ func (s *store) GetAll() []Config {
 
 	return copy
 }
+
+// Select returns the jobs whose Metadata is matched by sel, see MetadataOf
+func (s *store) Select(sel Selector) []Config {
+	s.RLock()
+	defer s.RUnlock()
+
+	var selected []Config
+
+	for _, val := range s.jobs {
+		if sel(MetadataOf(val)) {
+			selected = append(selected, val)
+		}
+	}
+
+	return selected
+}
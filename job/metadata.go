@@ -0,0 +1,97 @@
+package job
+
+// Metadata describes a job, e.g. to manage the jobs owned by a team together
+// It is attached when the job is scheduled and does not affect how the job runs
+type Metadata struct {
+	Tags        []string
+	Owner       string
+	Description string
+	Labels      map[string]string
+}
+
+// HasTag reports whether tag is one of the tags of m
+func (m Metadata) HasTag(tag string) bool {
+	for _, t := range m.Tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Copy returns a deep copy of m which shares no tags or labels with it
+func (m Metadata) Copy() Metadata {
+	if m.Tags != nil {
+		m.Tags = append(make([]string, 0, len(m.Tags)), m.Tags...)
+	}
+
+	if m.Labels != nil {
+		labels := make(map[string]string, len(m.Labels))
+		for k, v := range m.Labels {
+			labels[k] = v
+		}
+
+		m.Labels = labels
+	}
+
+	return m
+}
+
+// Describer is implemented by a Config which carries the Metadata of its job
+type Describer interface {
+	Metadata() Metadata
+}
+
+// MetadataOf returns the Metadata of c, it is empty when c is not a Describer
+func MetadataOf(c Config) Metadata {
+	if d, ok := c.(Describer); ok {
+		return d.Metadata()
+	}
+
+	return Metadata{}
+}
+
+// Selector matches jobs by their Metadata
+type Selector func(m Metadata) bool
+
+// ByTag returns a Selector matching the jobs which have all of tags
+func ByTag(tags ...string) Selector {
+	return func(m Metadata) bool {
+		for _, tag := range tags {
+			if !m.HasTag(tag) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// ByOwner returns a Selector matching the jobs owned by owner
+func ByOwner(owner string) Selector {
+	return func(m Metadata) bool {
+		return m.Owner == owner
+	}
+}
+
+// ByLabel returns a Selector matching the jobs with the label key set to value
+func ByLabel(key, value string) Selector {
+	return func(m Metadata) bool {
+		v, ok := m.Labels[key]
+		return ok && v == value
+	}
+}
+
+// MatchAll returns a Selector matching the jobs matched by every one of selectors
+func MatchAll(selectors ...Selector) Selector {
+	return func(m Metadata) bool {
+		for _, sel := range selectors {
+			if !sel(m) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
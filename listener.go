@@ -0,0 +1,87 @@
+package cronalt
+
+import (
+	"context"
+	"time"
+)
+
+// Event describes something which happened to a job
+// Fields which do not apply to an event are left as their zero value
+type Event struct {
+	// Job is the name of the job
+	Job string
+	// RunID identifies a single run of the job, it is empty for events which are not tied to a run
+	RunID string
+	// Time is when the event occurred
+	Time time.Time
+	// StartedAt is when the run started, set once the run started
+	StartedAt time.Time
+	// Duration is how long the run took, set once the run ended
+	Duration time.Duration
+	// NextRun is the scheduled time of the job's next run, set by OnNextRunComputed
+	NextRun time.Time
+	// Err is the error returned by the run, the recovered panic wrapped in ErrJobPanicked
+	// or a timeout wrapped in ErrJobTimedOut or ErrJobAbandoned
+	Err error
+	// Reason explains why a run was skipped
+	Reason string
+}
+
+// Listener receives the lifecycle events of all jobs in a Scheduler
+// Methods are called synchronously from the goroutine the event occurred in and should return quickly
+// Embed NoopListener to only implement the events you are interested in
+type Listener interface {
+	// OnScheduled is called when a job is registered, before any other event of the job
+	// It is called while the Scheduler registers the job so it must not call back into the Scheduler
+	OnScheduled(ctx context.Context, e Event)
+	// OnQueued is called when a run is waiting for a slot in the job pool
+	OnQueued(ctx context.Context, e Event)
+	// OnStarted is called when a run acquired a slot and is about to run
+	OnStarted(ctx context.Context, e Event)
+	// OnCompleted is called when a run returned without error
+	OnCompleted(ctx context.Context, e Event)
+	// OnFailed is called when a run returned an error
+	OnFailed(ctx context.Context, e Event)
+	// OnPanic is called when a run panicked
+	OnPanic(ctx context.Context, e Event)
+	// OnTimedOut is called when a run outlived its timeout or was abandoned, see WithTimeout and WithAbandonAfter
+	OnTimedOut(ctx context.Context, e Event)
+	// OnSkipped is called when a run was dropped, Event.Reason explains why
+	OnSkipped(ctx context.Context, e Event)
+	// OnNextRunComputed is called when the next scheduled time of a job is computed
+	OnNextRunComputed(ctx context.Context, e Event)
+	// OnFinished is called when the timer of a job has no further runs, see StateFinished
+	OnFinished(ctx context.Context, e Event)
+}
+
+// NoopListener implements Listener and ignores all events
+type NoopListener struct{}
+
+var _ Listener = NoopListener{}
+
+func (NoopListener) OnScheduled(_ context.Context, _ Event) {}
+
+func (NoopListener) OnQueued(_ context.Context, _ Event) {}
+
+func (NoopListener) OnStarted(_ context.Context, _ Event) {}
+
+func (NoopListener) OnCompleted(_ context.Context, _ Event) {}
+
+func (NoopListener) OnFailed(_ context.Context, _ Event) {}
+
+func (NoopListener) OnPanic(_ context.Context, _ Event) {}
+
+func (NoopListener) OnTimedOut(_ context.Context, _ Event) {}
+
+func (NoopListener) OnSkipped(_ context.Context, _ Event) {}
+
+func (NoopListener) OnNextRunComputed(_ context.Context, _ Event) {}
+
+func (NoopListener) OnFinished(_ context.Context, _ Event) {}
+
+// notify calls fn for every registered Listener
+func (s *Scheduler) notify(fn func(l Listener)) {
+	for _, l := range s.listeners {
+		fn(l)
+	}
+}
@@ -0,0 +1,75 @@
+package cronalt
+
+import (
+	"time"
+
+	"github.com/ahmedalhulaibi/cronalt/job"
+)
+
+// MisfirePolicy decides when a job runs given a scheduled time which passed before it could fire
+// missed is the first scheduled time which was missed and now is the current time
+// A returned time at or before now fires immediately and the following run is computed from it
+type MisfirePolicy func(missed, now time.Time, t job.Timer) time.Time
+
+// maxMisfireSteps bounds how many missed occurrences a MisfirePolicy walks through before giving up
+const maxMisfireSteps = 1024
+
+var (
+	_ MisfirePolicy = MisfireFireOnce
+	_ MisfirePolicy = MisfireFireAll
+	_ MisfirePolicy = MisfireSkip
+)
+
+// MisfireFireOnce fires once immediately for all the missed occurrences and continues from the latest one
+func MisfireFireOnce(missed, now time.Time, t job.Timer) time.Time {
+	latest, _, ok := walkMissed(missed, now, t)
+	if !ok {
+		return now
+	}
+
+	return latest
+}
+
+// MisfireFireAll fires every missed occurrence back to back
+func MisfireFireAll(missed, _ time.Time, _ job.Timer) time.Time {
+	return missed
+}
+
+// MisfireSkip drops all the missed occurrences and waits for the next future one
+func MisfireSkip(missed, now time.Time, t job.Timer) time.Time {
+	_, upcoming, ok := walkMissed(missed, now, t)
+	if !ok {
+		return t.Next(now)
+	}
+
+	return upcoming
+}
+
+// MisfireGrace returns a MisfirePolicy which fires a missed occurrence if it is late by at most grace
+// Occurrences later than grace are skipped like MisfireSkip
+func MisfireGrace(grace time.Duration) MisfirePolicy {
+	return func(missed, now time.Time, t job.Timer) time.Time {
+		if now.Sub(missed) <= grace {
+			return missed
+		}
+
+		return MisfireSkip(missed, now, t)
+	}
+}
+
+// walkMissed walks the occurrences of t from missed and returns the latest one at or before now and the first one after now
+// upcoming is zero when t has no occurrence after now, ok is false when more than maxMisfireSteps occurrences were missed
+func walkMissed(missed, now time.Time, t job.Timer) (latest, upcoming time.Time, ok bool) {
+	latest = missed
+
+	for i := 0; i < maxMisfireSteps; i++ {
+		upcoming = t.Next(latest)
+		if upcoming.IsZero() || upcoming.After(now) {
+			return latest, upcoming, true
+		}
+
+		latest = upcoming
+	}
+
+	return latest, upcoming, false
+}
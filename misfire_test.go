@@ -0,0 +1,86 @@
+package cronalt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMisfirePolicy(t *testing.T) {
+	nowFixture := time.Date(2021, 01, 01, 01, 01, 01, 01, time.UTC)
+	missedFixture := nowFixture.Add(-45 * time.Minute)
+
+	tests := map[string]struct {
+		policy MisfirePolicy
+		missed time.Time
+		want   time.Time
+	}{
+		"Should fire once for the latest missed occurrence": {
+			policy: MisfireFireOnce,
+			missed: missedFixture,
+			want:   missedFixture.Add(40 * time.Minute),
+		},
+		"Should fire every missed occurrence": {
+			policy: MisfireFireAll,
+			missed: missedFixture,
+			want:   missedFixture,
+		},
+		"Should skip to the next future occurrence": {
+			policy: MisfireSkip,
+			missed: missedFixture,
+			want:   missedFixture.Add(50 * time.Minute),
+		},
+		"Should fire a missed occurrence within the grace window": {
+			policy: MisfireGrace(time.Hour),
+			missed: missedFixture,
+			want:   missedFixture,
+		},
+		"Should skip a missed occurrence later than the grace window": {
+			policy: MisfireGrace(time.Minute),
+			missed: missedFixture,
+			want:   missedFixture.Add(50 * time.Minute),
+		},
+		"Should fire once now when too many occurrences were missed": {
+			policy: MisfireFireOnce,
+			missed: nowFixture.Add(-maxMisfireSteps * 20 * time.Minute),
+			want:   nowFixture,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, tt.policy(tt.missed, nowFixture, Every(10*time.Minute)))
+		})
+	}
+}
+
+func TestMisfirePolicy_Exhausted(t *testing.T) {
+	nowFixture := time.Date(2021, 01, 01, 01, 01, 01, 01, time.UTC)
+	last := nowFixture.Add(-10 * time.Minute)
+	timer := Bounded(Every(10*time.Minute), time.Time{}, last)
+
+	tests := map[string]struct {
+		policy MisfirePolicy
+		want   time.Time
+	}{
+		"Should fire once for the last occurrence": {
+			policy: MisfireFireOnce,
+			want:   last,
+		},
+		"Should return zero when skipping past the last occurrence": {
+			policy: MisfireSkip,
+			want:   time.Time{},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, tt.policy(last.Add(-20*time.Minute), nowFixture, timer))
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package cronalt
+
+// OverlapPolicy decides what happens when a job is due while a previous run of it is still in progress
+type OverlapPolicy struct {
+	// limit is the maximum number of concurrent runs of a job, zero runs the job sequentially
+	limit int
+}
+
+var (
+	// OverlapQueue runs a job sequentially, runs which become due while the previous one is in progress
+	// fire once after it completed whatever the job's MisfirePolicy, this is the default
+	OverlapQueue = OverlapPolicy{}
+	// OverlapSkip drops runs which become due while the previous one is in progress
+	OverlapSkip = OverlapAllow(1)
+)
+
+// OverlapAllow returns an OverlapPolicy which allows up to n concurrent runs of a job
+// Runs which become due while n runs are in progress are dropped
+func OverlapAllow(n int) OverlapPolicy {
+	if n < 1 {
+		n = 1
+	}
+
+	return OverlapPolicy{limit: n}
+}
+
+// sequential reports whether runs are executed one after the other by the job loop
+func (o OverlapPolicy) sequential() bool {
+	return o.limit == 0
+}
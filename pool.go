@@ -0,0 +1,166 @@
+package cronalt
+
+import (
+	"sync"
+	"time"
+)
+
+// Priority is the class of a job waiting for a slot in the job pool, slots are granted to higher classes first
+// Any int is a valid class, the constants below are provided for convenience
+type Priority int
+
+const (
+	PriorityLow      Priority = -1
+	PriorityNormal   Priority = 0
+	PriorityHigh     Priority = 1
+	PriorityCritical Priority = 2
+)
+
+// jobPool is a semaphore limiting the number of concurrent runs which grants its slots by priority
+// Waiters of the same effective priority are granted slots in the order they arrived
+type jobPool struct {
+	mu   sync.Mutex
+	size int
+	// aging raises the effective priority of a waiter by one class for every aging it waited, zero disables aging
+	aging time.Duration
+	// reserved holds the number of slots only granted to each class, inUse the number of slots held by each class
+	reserved map[Priority]int
+	inUse    map[Priority]int
+	used     int
+
+	waiters []*poolWaiter
+}
+
+type poolWaiter struct {
+	priority Priority
+	since    time.Time
+	// ready is closed once the waiter is granted a slot
+	ready   chan empty
+	granted bool
+}
+
+func newJobPool(size int) *jobPool {
+	return &jobPool{
+		size:     size,
+		reserved: make(map[Priority]int),
+		inUse:    make(map[Priority]int),
+	}
+}
+
+// reservedSlots returns the total number of reserved slots
+func (p *jobPool) reservedSlots() int {
+	var n int
+	for _, r := range p.reserved {
+		n += r
+	}
+
+	return n
+}
+
+// wait queues a waiter of class priority and grants it a slot right away if one is free
+func (p *jobPool) wait(priority Priority, now time.Time) *poolWaiter {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	w := &poolWaiter{priority: priority, since: now, ready: make(chan empty)}
+	p.waiters = append(p.waiters, w)
+	p.grant(now)
+
+	return w
+}
+
+// tryAcquire takes a free slot for class priority without queuing, it returns false when none fits
+func (p *jobPool) tryAcquire(priority Priority) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.used >= p.size || !p.fits(priority) {
+		return false
+	}
+
+	p.used++
+	p.inUse[priority]++
+
+	return true
+}
+
+// cancel removes a waiter which stopped waiting, it returns false when the waiter was already granted a slot
+func (p *jobPool) cancel(w *poolWaiter) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if w.granted {
+		return false
+	}
+
+	for i, other := range p.waiters {
+		if other == w {
+			p.waiters = append(p.waiters[:i], p.waiters[i+1:]...)
+			break
+		}
+	}
+
+	return true
+}
+
+// release frees a slot held by class priority and grants it to the next waiter
+func (p *jobPool) release(priority Priority, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.used--
+	p.inUse[priority]--
+	p.grant(now)
+}
+
+// grant hands out free slots to waiters by effective priority, it is called with p.mu held
+// A waiter which only fits in slots reserved for other classes is passed over for the waiters behind it
+func (p *jobPool) grant(now time.Time) {
+	for p.used < p.size && len(p.waiters) > 0 {
+		best := -1
+
+		for i, w := range p.waiters {
+			if !p.fits(w.priority) {
+				continue
+			}
+
+			if best < 0 || p.effective(w.priority, w.since, now) > p.effective(p.waiters[best].priority, p.waiters[best].since, now) {
+				best = i
+			}
+		}
+
+		if best < 0 {
+			return
+		}
+
+		w := p.waiters[best]
+		p.waiters = append(p.waiters[:best], p.waiters[best+1:]...)
+
+		p.used++
+		p.inUse[w.priority]++
+		w.granted = true
+		close(w.ready)
+	}
+}
+
+// fits reports whether a free slot is left for class priority once the unused reserved slots of other classes are set aside
+func (p *jobPool) fits(priority Priority) bool {
+	free := p.size - p.used
+
+	for class, n := range p.reserved {
+		if unused := n - p.inUse[class]; class != priority && unused > 0 {
+			free -= unused
+		}
+	}
+
+	return free > 0
+}
+
+// effective returns the priority of class priority raised by aging for waiting since since
+func (p *jobPool) effective(priority Priority, since, now time.Time) Priority {
+	if p.aging <= 0 {
+		return priority
+	}
+
+	return priority + Priority(now.Sub(since)/p.aging)
+}
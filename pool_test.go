@@ -0,0 +1,306 @@
+package cronalt
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func granted(w *poolWaiter) bool {
+	select {
+	case <-w.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+func Test_jobPool(t *testing.T) {
+	nowFixture := time.Date(2021, 01, 01, 01, 01, 01, 01, time.UTC)
+
+	type waiter struct {
+		priority Priority
+		// after is how long after nowFixture the waiter arrives
+		after time.Duration
+	}
+
+	tests := map[string]struct {
+		size     int
+		aging    time.Duration
+		reserved map[Priority]int
+		// holders take the slots of the pool before waiters arrive, they are released one at a time releaseAfter nowFixture
+		holders      []Priority
+		releaseAfter time.Duration
+		waiters      []waiter
+		// want is the index of the waiters in the order they are granted a slot
+		want []int
+	}{
+		"Should grant slots to higher classes first": {
+			size:    1,
+			holders: []Priority{PriorityNormal},
+			waiters: []waiter{{priority: PriorityLow}, {priority: PriorityNormal}, {priority: PriorityCritical}, {priority: PriorityHigh}},
+			want:    []int{2, 3, 1, 0},
+		},
+		"Should grant slots in order of arrival within a class": {
+			size:    1,
+			holders: []Priority{PriorityNormal},
+			waiters: []waiter{{priority: PriorityHigh}, {priority: PriorityHigh, after: time.Second}, {priority: PriorityHigh, after: 2 * time.Second}},
+			want:    []int{0, 1, 2},
+		},
+		"Should raise the priority of waiters which waited long": {
+			size:         1,
+			aging:        time.Second,
+			holders:      []Priority{PriorityNormal},
+			releaseAfter: 3 * time.Second,
+			waiters:      []waiter{{priority: PriorityLow}, {priority: PriorityHigh, after: 3 * time.Second}},
+			want:         []int{0, 1},
+		},
+		"Should keep reserved slots for their class": {
+			size:     2,
+			reserved: map[Priority]int{PriorityCritical: 1},
+			holders:  []Priority{PriorityNormal},
+			waiters:  []waiter{{priority: PriorityNormal}, {priority: PriorityHigh}, {priority: PriorityCritical}},
+			want:     []int{2, 1, 0},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			p := newJobPool(tt.size)
+			p.aging = tt.aging
+			for class, n := range tt.reserved {
+				p.reserved[class] = n
+			}
+
+			for _, h := range tt.holders {
+				require.True(t, granted(p.wait(h, nowFixture)))
+			}
+
+			holding := append([]Priority{}, tt.holders...)
+			waiters := make([]*poolWaiter, len(tt.waiters))
+			for i, w := range tt.waiters {
+				waiters[i] = p.wait(w.priority, nowFixture.Add(w.after))
+			}
+
+			var got []int
+			collect := func() {
+				for i, w := range waiters {
+					if w != nil && granted(w) {
+						got = append(got, i)
+						holding = append(holding, w.priority)
+						waiters[i] = nil
+					}
+				}
+			}
+
+			collect()
+			for len(got) < len(tt.want) {
+				require.NotEmpty(t, holding, "no waiter was granted a slot, got %v", got)
+
+				var class Priority
+				class, holding = holding[0], holding[1:]
+				p.release(class, nowFixture.Add(tt.releaseAfter))
+
+				collect()
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_jobPool_cancel(t *testing.T) {
+	nowFixture := time.Date(2021, 01, 01, 01, 01, 01, 01, time.UTC)
+
+	t.Run("Should not grant a slot to a waiter which gave up", func(t *testing.T) {
+		p := newJobPool(1)
+		require.True(t, granted(p.wait(PriorityNormal, nowFixture)))
+
+		gaveUp := p.wait(PriorityHigh, nowFixture)
+		next := p.wait(PriorityNormal, nowFixture)
+		require.True(t, p.cancel(gaveUp))
+
+		p.release(PriorityNormal, nowFixture)
+		assert.False(t, granted(gaveUp))
+		assert.True(t, granted(next))
+	})
+	t.Run("Should report a waiter which was granted a slot", func(t *testing.T) {
+		p := newJobPool(1)
+		w := p.wait(PriorityNormal, nowFixture)
+
+		assert.False(t, p.cancel(w))
+	})
+}
+
+func TestNewScheduler_ReservedSlots(t *testing.T) {
+	_, err := NewScheduler(2, WithReservedSlots(PriorityCritical, 2), WithReservedSlots(PriorityHigh, 1))
+	assert.ErrorIs(t, err, ErrTooManyReservedSlots)
+
+	_, err = NewScheduler(3, WithReservedSlots(PriorityCritical, 2), WithReservedSlots(PriorityHigh, 1))
+	assert.NoError(t, err)
+}
+
+func TestScheduler_ReservedSlots(t *testing.T) {
+	tests := map[string]struct {
+		opts []SchedulerOption
+	}{
+		"Should run a job in its reserved slot while others are queued": {},
+		"Should run a job in its reserved slot while others are queued with the heap dispatcher": {
+			opts: []SchedulerOption{WithHeapDispatcher()},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := NewScheduler(2, append(tt.opts, WithReservedSlots(PriorityHigh, 1))...)
+			require.NoError(t, err)
+
+			release := make(chan empty)
+
+			for i := 0; i < 3; i++ {
+				bj := blockingJob{name: fmt.Sprint("low-", i), started: make(chan empty, 1), release: release}
+				require.NoError(t, s.Schedule(Every(time.Millisecond), bj, WithPriority(PriorityLow)))
+			}
+
+			// high is first due once runs of the low jobs are queued for the slots left
+			ran := make(chan string)
+			require.NoError(t, s.Schedule(Every(20*time.Millisecond), signalJob{name: "high", ran: ran}, WithPriority(PriorityHigh)))
+
+			startScheduler(t, s)
+			defer func() {
+				close(release)
+				assert.NoError(t, s.Shutdown(context.Background()))
+			}()
+
+			select {
+			case <-ran:
+			case <-time.After(time.Second):
+				t.Fatal("the job with a reserved slot did not run while others were queued")
+			}
+		})
+	}
+}
+
+func TestScheduler_ConcurrencyGroups(t *testing.T) {
+	tests := map[string]struct {
+		opts     []SchedulerOption
+		jobs     [][]string
+		wantPeak int32
+	}{
+		"Should limit the concurrent runs of a group": {
+			opts:     []SchedulerOption{WithConcurrencyGroup("db", 1)},
+			jobs:     [][]string{{"db"}, {"db"}, {"db"}},
+			wantPeak: 1,
+		},
+		"Should limit the concurrent runs of jobs in several groups": {
+			opts:     []SchedulerOption{WithConcurrencyGroup("db", 2), WithConcurrencyGroup("api", 1)},
+			jobs:     [][]string{{"db", "api"}, {"api", "db"}, {"db"}},
+			wantPeak: 2,
+		},
+		"Should not limit jobs outside of a group": {
+			opts:     []SchedulerOption{WithConcurrencyGroup("db", 1)},
+			jobs:     [][]string{nil, nil, {"db"}},
+			wantPeak: 3,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var running, peak int32
+
+			s, err := NewScheduler(10, tt.opts...)
+			require.NoError(t, err)
+
+			for i, groups := range tt.jobs {
+				j := concurrencyJob{name: fmt.Sprint("job-", i), running: &running, peak: &peak}
+				require.NoError(t, s.Schedule(Every(time.Hour), j, WithGroups(groups...)))
+			}
+
+			var wg sync.WaitGroup
+			for i := range tt.jobs {
+				wg.Add(1)
+
+				go func(name string) {
+					defer wg.Done()
+					assert.NoError(t, s.Trigger(context.Background(), name, TriggerWait()))
+				}(fmt.Sprint("job-", i))
+			}
+			wg.Wait()
+
+			assert.Equal(t, tt.wantPeak, atomic.LoadInt32(&peak))
+		})
+	}
+	t.Run("Should run jobs outside of a busy group", func(t *testing.T) {
+		for name, opts := range map[string][]SchedulerOption{
+			"loop": nil,
+			"heap": {WithHeapDispatcher()},
+		} {
+			opts := opts
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				s, err := NewScheduler(2, append(opts, WithConcurrencyGroup("db", 1))...)
+				require.NoError(t, err)
+
+				release := make(chan empty)
+
+				for i := 0; i < 3; i++ {
+					bj := blockingJob{name: fmt.Sprint("db-", i), started: make(chan empty, 1), release: release}
+					require.NoError(t, s.Schedule(Every(time.Millisecond), bj, WithGroups("db")))
+				}
+
+				ran := make(chan string)
+				require.NoError(t, s.Schedule(Every(time.Millisecond), signalJob{name: "outside", ran: ran}))
+
+				startScheduler(t, s)
+				defer func() {
+					close(release)
+					assert.NoError(t, s.Shutdown(context.Background()))
+				}()
+
+				select {
+				case <-ran:
+				case <-time.After(time.Second):
+					t.Fatal("the job outside of the group did not run while the group was busy")
+				}
+			})
+		}
+	})
+	t.Run("Should reject a job in an unknown group", func(t *testing.T) {
+		s, err := NewScheduler(1, WithConcurrencyGroup("db", 1))
+		require.NoError(t, err)
+
+		err = s.Schedule(Every(time.Hour), errJob{}, WithGroups("db", "api"))
+		assert.ErrorIs(t, err, ErrUnknownGroup)
+	})
+	t.Run("Should reject a group without slots", func(t *testing.T) {
+		_, err := NewScheduler(1, WithConcurrencyGroup("db", 0))
+		assert.ErrorIs(t, err, ErrGroupLimitZero)
+	})
+	t.Run("Should return ErrPoolSaturated when max wait elapses on a group", func(t *testing.T) {
+		s, err := NewScheduler(2, WithConcurrencyGroup("db", 1))
+		require.NoError(t, err)
+		require.NoError(t, s.acquire(context.Background(), 0, PriorityNormal, "db"))
+
+		err = s.acquire(context.Background(), time.Millisecond, PriorityNormal, "db")
+		assert.ErrorIs(t, err, ErrPoolSaturated)
+
+		// The slot of the job pool is free for jobs outside of the group
+		assert.NoError(t, s.acquire(context.Background(), time.Millisecond, PriorityNormal))
+	})
+}
@@ -0,0 +1,397 @@
+package rrule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ahmedalhulaibi/cronalt/internal/wallclock"
+)
+
+// ErrInvalidRecurrence is wrapped by every error returned by Parse
+var ErrInvalidRecurrence error = fmt.Errorf("invalid recurrence")
+
+const (
+	dateTimeLayout = "20060102T150405"
+	dateLayout     = "20060102"
+)
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+var freqs = map[string]freq{
+	"YEARLY": yearly, "MONTHLY": monthly, "WEEKLY": weekly, "DAILY": daily,
+	"HOURLY": hourly, "MINUTELY": minutely, "SECONDLY": secondly,
+}
+
+// Parse parses DTSTART, RRULE, RDATE and EXDATE content lines separated by new lines, e.g.
+//
+//	DTSTART;TZID=America/New_York:19970902T090000
+//	RRULE:FREQ=MONTHLY;BYDAY=2TU;COUNT=10
+//	EXDATE;TZID=America/New_York:19970909T090000
+//
+// DTSTART is required, RRULE, RDATE and EXDATE may be repeated and RDATE and EXDATE accept comma separated lists
+func Parse(s string) (*Set, error) {
+	set := &Set{exdates: make(map[time.Time]bool)}
+
+	var (
+		hasStart bool
+		rules    []string
+		rdates   []line
+		exdates  []line
+	)
+
+	for _, raw := range strings.Split(s, "\n") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		l, err := parseLine(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		switch l.name {
+		case "DTSTART":
+			if hasStart {
+				return nil, fmt.Errorf("%w:%s", ErrInvalidRecurrence, "DTSTART is repeated")
+			}
+
+			if set.dtstart, set.loc, err = l.time(l.value, nil); err != nil {
+				return nil, err
+			}
+
+			hasStart = true
+		case "RRULE":
+			rules = append(rules, l.value)
+		case "RDATE":
+			rdates = append(rdates, l)
+		case "EXDATE":
+			exdates = append(exdates, l)
+		default:
+			return nil, fmt.Errorf("%w:unsupported property %s", ErrInvalidRecurrence, l.name)
+		}
+	}
+
+	if !hasStart {
+		return nil, fmt.Errorf("%w:%s", ErrInvalidRecurrence, "missing DTSTART")
+	}
+
+	for _, value := range rules {
+		r, err := parseRule(value, set.dtstart, set.loc)
+		if err != nil {
+			return nil, err
+		}
+
+		set.rules = append(set.rules, r)
+	}
+
+	for _, l := range rdates {
+		times, err := l.times(set.loc)
+		if err != nil {
+			return nil, err
+		}
+
+		set.rdates = append(set.rdates, times...)
+	}
+
+	for _, l := range exdates {
+		times, err := l.times(set.loc)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range times {
+			set.exdates[t] = true
+		}
+	}
+
+	set.sortRDates()
+
+	return set, nil
+}
+
+// line is a content line, NAME;PARAM=VALUE:VALUE
+type line struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+func parseLine(raw string) (line, error) {
+	i := strings.IndexByte(raw, ':')
+	if i < 0 {
+		// A bare rule is accepted as an RRULE
+		if strings.Contains(raw, "FREQ=") {
+			return line{name: "RRULE", value: raw}, nil
+		}
+
+		return line{}, fmt.Errorf("%w:malformed line %q", ErrInvalidRecurrence, raw)
+	}
+
+	head := strings.Split(raw[:i], ";")
+	l := line{name: strings.ToUpper(head[0]), params: make(map[string]string), value: raw[i+1:]}
+
+	for _, p := range head[1:] {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 {
+			return line{}, fmt.Errorf("%w:malformed parameter %q", ErrInvalidRecurrence, p)
+		}
+
+		l.params[strings.ToUpper(kv[0])] = kv[1]
+	}
+
+	return l, nil
+}
+
+// times parses the comma separated date-times of an RDATE or EXDATE as wall clock times of loc
+func (l line) times(loc *time.Location) ([]time.Time, error) {
+	if v, ok := l.params["VALUE"]; ok && v != "DATE" && v != "DATE-TIME" {
+		return nil, fmt.Errorf("%w:unsupported %s value %s", ErrInvalidRecurrence, l.name, v)
+	}
+
+	var times []time.Time
+
+	for _, v := range strings.Split(l.value, ",") {
+		t, _, err := l.time(v, loc)
+		if err != nil {
+			return nil, err
+		}
+
+		times = append(times, t)
+	}
+
+	return times, nil
+}
+
+// time parses a date or date-time value as a wall clock time
+// The location is set by a TZID parameter or a UTC value and is nil for a floating time, such a time is read off the
+// wall clock of loc when loc is not nil
+func (l line) time(v string, loc *time.Location) (time.Time, *time.Location, error) {
+	var own *time.Location
+
+	if tz, ok := l.params["TZID"]; ok {
+		var err error
+		if own, err = time.LoadLocation(tz); err != nil {
+			return time.Time{}, nil, fmt.Errorf("%w:unknown TZID %q", ErrInvalidRecurrence, tz)
+		}
+	}
+
+	w, utc, err := parseDateTime(v)
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("%w:%s %q", ErrInvalidRecurrence, l.name, v)
+	}
+
+	if utc {
+		own = time.UTC
+	}
+
+	if own != nil && loc != nil && own != loc {
+		// Convert the instant to the wall clock the recurrence is evaluated on
+		instant := time.Date(w.Year(), w.Month(), w.Day(), w.Hour(), w.Minute(), w.Second(), 0, own)
+		w = wallclock.ToWall(instant, loc)
+	}
+
+	return w, own, nil
+}
+
+// parseDateTime parses a DATE or DATE-TIME value as a wall clock time, utc reports a trailing Z
+func parseDateTime(v string) (w time.Time, utc bool, err error) {
+	if strings.HasSuffix(v, "Z") {
+		v, utc = v[:len(v)-1], true
+	}
+
+	if len(v) == len(dateLayout) {
+		w, err = time.Parse(dateLayout, v)
+		return w, utc, err
+	}
+
+	w, err = time.Parse(dateTimeLayout, v)
+
+	return w, utc, err
+}
+
+func parseRule(value string, dtstart time.Time, loc *time.Location) (*rule, error) {
+	r := &rule{dtstart: dtstart, interval: 1, wkst: time.Monday}
+
+	errorf := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w:RRULE %q: %s", ErrInvalidRecurrence, value, fmt.Sprintf(format, args...))
+	}
+
+	var hasFreq bool
+
+	for _, part := range strings.Split(value, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, errorf("malformed part %q", part)
+		}
+
+		key, v := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+
+		var err error
+
+		switch key {
+		case "FREQ":
+			if r.freq, hasFreq = freqs[v]; !hasFreq {
+				return nil, errorf("unknown FREQ %s", v)
+			}
+		case "INTERVAL":
+			if r.interval, err = strconv.Atoi(v); err != nil || r.interval < 1 {
+				return nil, errorf("invalid INTERVAL %s", v)
+			}
+		case "COUNT":
+			if r.count, err = strconv.Atoi(v); err != nil || r.count < 1 {
+				return nil, errorf("invalid COUNT %s", v)
+			}
+		case "UNTIL":
+			w, utc, err := parseDateTime(v)
+			if err != nil {
+				return nil, errorf("invalid UNTIL %s", v)
+			}
+
+			if len(strings.TrimSuffix(v, "Z")) == len(dateLayout) {
+				// A date includes the whole day
+				w = w.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			}
+
+			if utc && loc != nil && loc != time.UTC {
+				w = wallclock.ToWall(time.Date(w.Year(), w.Month(), w.Day(), w.Hour(), w.Minute(), w.Second(), w.Nanosecond(), time.UTC), loc)
+			}
+
+			r.until = w
+		case "BYSECOND":
+			r.bySecond, err = parseInts(v, 0, 59, false)
+		case "BYMINUTE":
+			r.byMinute, err = parseInts(v, 0, 59, false)
+		case "BYHOUR":
+			r.byHour, err = parseInts(v, 0, 23, false)
+		case "BYMONTHDAY":
+			r.byMonthDay, err = parseInts(v, 1, 31, true)
+		case "BYYEARDAY":
+			r.byYearDay, err = parseInts(v, 1, 366, true)
+		case "BYWEEKNO":
+			r.byWeekNo, err = parseInts(v, 1, 53, true)
+		case "BYMONTH":
+			r.byMonth, err = parseInts(v, 1, 12, false)
+		case "BYSETPOS":
+			r.bySetPos, err = parseInts(v, 1, 366, true)
+		case "BYDAY":
+			r.byDay, err = parseWeekdays(v)
+		case "WKST":
+			var ok bool
+			if r.wkst, ok = weekdays[v]; !ok {
+				return nil, errorf("invalid WKST %s", v)
+			}
+		default:
+			return nil, errorf("unsupported part %s", key)
+		}
+
+		if err != nil {
+			return nil, errorf("invalid %s: %v", key, err)
+		}
+	}
+
+	if !hasFreq {
+		return nil, errorf("missing FREQ")
+	}
+
+	if r.count > 0 && !r.until.IsZero() {
+		return nil, errorf("COUNT and UNTIL are mutually exclusive")
+	}
+
+	if r.freq != yearly && r.freq != monthly {
+		for _, d := range r.byDay {
+			if d.n != 0 {
+				return nil, errorf("BYDAY ordinals require FREQ=MONTHLY or FREQ=YEARLY")
+			}
+		}
+	}
+
+	if r.freq != yearly && len(r.byWeekNo) > 0 {
+		return nil, errorf("BYWEEKNO requires FREQ=YEARLY")
+	}
+
+	r.defaults()
+
+	return r, nil
+}
+
+// defaults fills in the parts implied by DTSTART when a rule does not restrict the days it runs on
+func (r *rule) defaults() {
+	noDays := len(r.byYearDay) == 0 && len(r.byWeekNo) == 0 && len(r.byMonthDay) == 0 && len(r.byDay) == 0
+
+	switch r.freq {
+	case yearly:
+		if noDays {
+			r.byMonthDay = []int{r.dtstart.Day()}
+			if len(r.byMonth) == 0 {
+				r.byMonth = []int{int(r.dtstart.Month())}
+			}
+		}
+	case monthly:
+		if noDays {
+			r.byMonthDay = []int{r.dtstart.Day()}
+		}
+	case weekly:
+		if noDays {
+			r.byDay = []weekdayNum{{day: r.dtstart.Weekday()}}
+		}
+	}
+}
+
+// parseInts parses a comma separated list of integers between min and max, or -max and -min when negative is true
+func parseInts(v string, min, max int, negative bool) ([]int, error) {
+	var out []int
+
+	for _, s := range strings.Split(v, ",") {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, err
+		}
+
+		abs := n
+		if negative && n < 0 {
+			abs = -n
+		}
+
+		if abs < min || abs > max {
+			return nil, fmt.Errorf("%d out of range", n)
+		}
+
+		out = append(out, n)
+	}
+
+	return out, nil
+}
+
+// parseWeekdays parses a comma separated list of weekdays with an optional ordinal, e.g. MO,2TU,-1FR
+func parseWeekdays(v string) ([]weekdayNum, error) {
+	var out []weekdayNum
+
+	for _, s := range strings.Split(v, ",") {
+		if len(s) < 2 {
+			return nil, fmt.Errorf("invalid weekday %q", s)
+		}
+
+		day, ok := weekdays[s[len(s)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %q", s)
+		}
+
+		var n int
+		if prefix := s[:len(s)-2]; prefix != "" {
+			var err error
+			if n, err = strconv.Atoi(prefix); err != nil || n == 0 || n < -53 || n > 53 {
+				return nil, fmt.Errorf("invalid weekday %q", s)
+			}
+		}
+
+		out = append(out, weekdayNum{n: n, day: day})
+	}
+
+	return out, nil
+}
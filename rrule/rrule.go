@@ -0,0 +1,463 @@
+// Package rrule runs iCalendar (RFC 5545) recurrences as job timers
+//
+// A recurrence set is built from DTSTART, RRULE, RDATE and EXDATE properties, see Parse. Occurrences are generated on
+// the wall clock of the DTSTART time zone, a TZID parameter or UTC, and a floating DTSTART is evaluated in the location
+// of the previous run. Around daylight saving time transitions a wall clock time skipped by a gap runs when the gap
+// ends and a wall clock time repeated by an overlap runs once.
+//
+// Like most implementations, a DTSTART which does not match its RRULE is not an occurrence, add it as an RDATE to run it.
+package rrule
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ahmedalhulaibi/cronalt/internal/wallclock"
+	"github.com/ahmedalhulaibi/cronalt/job"
+)
+
+// maxPeriods bounds how many periods in a row without an occurrence are generated looking for the next occurrence
+// A rule which has no occurrence in that many periods, e.g. February 30th, is treated as having no further occurrences
+const maxPeriods = 100000
+
+type freq int
+
+const (
+	secondly freq = iota
+	minutely
+	hourly
+	daily
+	weekly
+	monthly
+	yearly
+)
+
+type weekdayNum struct {
+	// n is the ordinal of the weekday within the month or year, negative counts from the end and zero is every one
+	n   int
+	day time.Weekday
+}
+
+type rule struct {
+	dtstart  time.Time
+	freq     freq
+	interval int
+	count    int
+	until    time.Time
+
+	bySecond, byMinute, byHour      []int
+	byDay                           []weekdayNum
+	byMonthDay, byYearDay, byWeekNo []int
+	byMonth, bySetPos               []int
+	wkst                            time.Weekday
+
+	// mu guards cursor, a rule with COUNT resumes counting its occurrences from there rather than from DTSTART
+	mu     sync.Mutex
+	cursor ruleCursor
+}
+
+// ruleCursor is the position of the last occurrence returned by a rule
+type ruleCursor struct {
+	// period is the start of the period of the occurrence and counted the number of occurrences before that period
+	period  time.Time
+	counted int
+	last    time.Time
+}
+
+// Set is a recurrence set, it implements job.Timer and Next returns zero once every occurrence passed
+type Set struct {
+	// dtstart, rdates and exdates are wall clock times of loc
+	dtstart time.Time
+	loc     *time.Location
+	rules   []*rule
+	rdates  []time.Time
+	exdates map[time.Time]bool
+}
+
+var _ job.Timer = (*Set)(nil)
+
+// Location returns the time zone of DTSTART, nil when it is a floating time evaluated in the location of the previous run
+func (s *Set) Location() *time.Location {
+	return s.loc
+}
+
+func (s *Set) Next(prevStart time.Time) time.Time {
+	loc := s.loc
+	if loc == nil {
+		loc = prevStart.Location()
+	}
+
+	return wallclock.In(wallSet{s}, loc).Next(prevStart)
+}
+
+func (s *Set) sortRDates() {
+	sort.Slice(s.rdates, func(i, j int) bool { return s.rdates[i].Before(s.rdates[j]) })
+}
+
+// wallSet generates the occurrences of the Set as wall clock times
+type wallSet struct {
+	*Set
+}
+
+func (s wallSet) Next(w time.Time) time.Time {
+	var next time.Time
+
+	earliest := func(t time.Time) {
+		if !t.IsZero() && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+
+	for _, r := range s.rules {
+		t := r.next(w)
+		for !t.IsZero() && s.exdates[t] {
+			t = r.next(t)
+		}
+
+		earliest(t)
+	}
+
+	i := sort.Search(len(s.rdates), func(i int) bool { return s.rdates[i].After(w) })
+	for ; i < len(s.rdates); i++ {
+		if !s.exdates[s.rdates[i]] {
+			earliest(s.rdates[i])
+			break
+		}
+	}
+
+	return next
+}
+
+// next returns the first occurrence of the rule after the wall clock time w, zero when there is none
+func (r *rule) next(w time.Time) time.Time {
+	p, counted := r.resume(w)
+
+	for empty := 0; empty < maxPeriods; {
+		if !r.until.IsZero() && p.After(r.until) {
+			return time.Time{}
+		}
+
+		before := counted
+
+		for _, t := range r.occurrences(p) {
+			if t.Before(r.dtstart) {
+				continue
+			}
+
+			if !r.until.IsZero() && t.After(r.until) {
+				return time.Time{}
+			}
+
+			counted++
+			if r.count > 0 && counted > r.count {
+				return time.Time{}
+			}
+
+			if t.After(w) {
+				r.remember(ruleCursor{period: p, counted: before, last: t})
+				return t
+			}
+		}
+
+		if counted == before {
+			empty++
+		} else {
+			empty = 0
+		}
+
+		p = r.addPeriods(p, r.interval)
+	}
+
+	return time.Time{}
+}
+
+// resume returns the period the search for the first occurrence after w starts from
+// and the number of occurrences before that period
+func (r *rule) resume(w time.Time) (time.Time, int) {
+	start := r.periodStart(r.dtstart)
+
+	// Without COUNT occurrences before w do not need to be generated, start from the period containing w
+	if r.count == 0 {
+		if !w.After(r.dtstart) {
+			return start, 0
+		}
+
+		k := r.periodsBetween(start, r.periodStart(w))
+
+		return r.addPeriods(start, k-k%r.interval), 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Occurrences are generated in order so the ones before the last occurrence returned are all at or before w
+	if !r.cursor.last.IsZero() && !w.Before(r.cursor.last) {
+		return r.cursor.period, r.cursor.counted
+	}
+
+	return start, 0
+}
+
+// remember records the position of an occurrence returned by a rule with COUNT
+func (r *rule) remember(c ruleCursor) {
+	if r.count == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cursor = c
+}
+
+// periodStart returns the start of the period of the rule's frequency containing t
+func (r *rule) periodStart(t time.Time) time.Time {
+	switch r.freq {
+	case yearly:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	case monthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case weekly:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -int((7+day.Weekday()-r.wkst)%7))
+	case daily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case hourly:
+		return t.Truncate(time.Hour)
+	case minutely:
+		return t.Truncate(time.Minute)
+	default:
+		return t.Truncate(time.Second)
+	}
+}
+
+// periodsBetween returns the number of periods from the start of period a to the start of period b
+func (r *rule) periodsBetween(a, b time.Time) int {
+	switch r.freq {
+	case yearly:
+		return b.Year() - a.Year()
+	case monthly:
+		return (b.Year()-a.Year())*12 + int(b.Month()) - int(a.Month())
+	case weekly:
+		return int(b.Sub(a) / (7 * 24 * time.Hour))
+	case daily:
+		return int(b.Sub(a) / (24 * time.Hour))
+	case hourly:
+		return int(b.Sub(a) / time.Hour)
+	case minutely:
+		return int(b.Sub(a) / time.Minute)
+	default:
+		return int(b.Sub(a) / time.Second)
+	}
+}
+
+func (r *rule) addPeriods(p time.Time, n int) time.Time {
+	switch r.freq {
+	case yearly:
+		return p.AddDate(n, 0, 0)
+	case monthly:
+		return p.AddDate(0, n, 0)
+	case weekly:
+		return p.AddDate(0, 0, 7*n)
+	case daily:
+		return p.AddDate(0, 0, n)
+	case hourly:
+		return p.Add(time.Duration(n) * time.Hour)
+	case minutely:
+		return p.Add(time.Duration(n) * time.Minute)
+	default:
+		return p.Add(time.Duration(n) * time.Second)
+	}
+}
+
+// occurrences returns the sorted occurrences of the rule in the period starting at p
+func (r *rule) occurrences(p time.Time) []time.Time {
+	hours := r.timeValues(r.byHour, hourly, r.dtstart.Hour(), p.Hour())
+	minutes := r.timeValues(r.byMinute, minutely, r.dtstart.Minute(), p.Minute())
+	seconds := r.timeValues(r.bySecond, secondly, r.dtstart.Second(), p.Second())
+
+	var out []time.Time
+
+	for _, day := range r.days(p) {
+		for _, h := range hours {
+			for _, m := range minutes {
+				for _, s := range seconds {
+					out = append(out, time.Date(day.Year(), day.Month(), day.Day(), h, m, s, 0, time.UTC))
+				}
+			}
+		}
+	}
+
+	return r.setPos(out)
+}
+
+// timeValues returns the sorted values of a time field
+// A field at or finer than the frequency is fixed to the period and limited by its BY part, a coarser field is
+// expanded by its BY part and otherwise taken from DTSTART
+func (r *rule) timeValues(by []int, unit freq, fromStart, fromPeriod int) []int {
+	if r.freq <= unit {
+		if len(by) == 0 || contains(by, fromPeriod) {
+			return []int{fromPeriod}
+		}
+
+		return nil
+	}
+
+	if len(by) == 0 {
+		return []int{fromStart}
+	}
+
+	values := append([]int(nil), by...)
+	sort.Ints(values)
+
+	return values
+}
+
+// days returns the days of the period starting at p which match the rule
+func (r *rule) days(p time.Time) []time.Time {
+	first, last := p, p
+
+	switch r.freq {
+	case yearly:
+		last = p.AddDate(1, 0, -1)
+	case monthly:
+		last = p.AddDate(0, 1, -1)
+	case weekly:
+		last = p.AddDate(0, 0, 6)
+	default:
+		first = time.Date(p.Year(), p.Month(), p.Day(), 0, 0, 0, 0, time.UTC)
+		last = first
+	}
+
+	var out []time.Time
+
+	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+		if r.dayMatches(d) {
+			out = append(out, d)
+		}
+	}
+
+	return out
+}
+
+func (r *rule) dayMatches(d time.Time) bool {
+	if len(r.byMonth) > 0 && !contains(r.byMonth, int(d.Month())) {
+		return false
+	}
+
+	if len(r.byWeekNo) > 0 && !r.weekNoMatches(d) {
+		return false
+	}
+
+	if len(r.byYearDay) > 0 {
+		days := daysIn(d.Year())
+		if !contains(r.byYearDay, d.YearDay()) && !contains(r.byYearDay, d.YearDay()-days-1) {
+			return false
+		}
+	}
+
+	if len(r.byMonthDay) > 0 {
+		days := time.Date(d.Year(), d.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		if !contains(r.byMonthDay, d.Day()) && !contains(r.byMonthDay, d.Day()-days-1) {
+			return false
+		}
+	}
+
+	if len(r.byDay) > 0 && !r.weekdayMatches(d) {
+		return false
+	}
+
+	return true
+}
+
+// weekdayMatches matches BYDAY, ordinals count within the month for monthly rules or yearly rules with BYMONTH
+// and within the year otherwise
+func (r *rule) weekdayMatches(d time.Time) bool {
+	var first, last time.Time
+	if r.freq == monthly || len(r.byMonth) > 0 {
+		first = time.Date(d.Year(), d.Month(), 1, 0, 0, 0, 0, time.UTC)
+		last = first.AddDate(0, 1, -1)
+	} else {
+		first = time.Date(d.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+		last = first.AddDate(1, 0, -1)
+	}
+
+	for _, wd := range r.byDay {
+		if wd.day != d.Weekday() {
+			continue
+		}
+
+		nth := int(d.Sub(first)/(24*time.Hour))/7 + 1
+		nthFromEnd := -(int(last.Sub(d)/(24*time.Hour))/7 + 1)
+
+		if wd.n == 0 || wd.n == nth || wd.n == nthFromEnd {
+			return true
+		}
+	}
+
+	return false
+}
+
+// weekNoMatches matches BYWEEKNO, week 1 is the first week starting on WKST with at least 4 days in the year
+func (r *rule) weekNoMatches(d time.Time) bool {
+	year := d.Year()
+	start := r.week1(year)
+
+	switch {
+	case d.Before(start):
+		year--
+		start = r.week1(year)
+	case !d.Before(r.week1(year + 1)):
+		year++
+		start = r.week1(year)
+	}
+
+	weeks := int(r.week1(year+1).Sub(start) / (7 * 24 * time.Hour))
+	no := int(d.Sub(start)/(7*24*time.Hour)) + 1
+
+	return contains(r.byWeekNo, no) || contains(r.byWeekNo, no-weeks-1)
+}
+
+// week1 returns the first day of week 1 of year
+func (r *rule) week1(year int) time.Time {
+	jan1 := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	offset := int((7 + jan1.Weekday() - r.wkst) % 7)
+
+	if offset <= 3 {
+		return jan1.AddDate(0, 0, -offset)
+	}
+
+	return jan1.AddDate(0, 0, 7-offset)
+}
+
+// setPos limits the sorted occurrences of a period to the positions of BYSETPOS
+func (r *rule) setPos(occurrences []time.Time) []time.Time {
+	if len(r.bySetPos) == 0 {
+		return occurrences
+	}
+
+	var out []time.Time
+
+	for i, t := range occurrences {
+		if contains(r.bySetPos, i+1) || contains(r.bySetPos, i-len(occurrences)) {
+			out = append(out, t)
+		}
+	}
+
+	return out
+}
+
+func daysIn(year int) int {
+	return time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC).YearDay()
+}
+
+func contains(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+
+	return false
+}
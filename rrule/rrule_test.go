@@ -0,0 +1,219 @@
+package rrule
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSet_Next(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	at := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, ny)
+	}
+
+	// Examples from RFC 5545 section 3.8.5.3
+	tests := map[string]struct {
+		recurrence string
+		prevStart  time.Time
+		want       []time.Time
+	}{
+		"Should run daily for 10 occurrences": {
+			recurrence: "DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=DAILY;COUNT=10",
+			prevStart:  at(1997, 9, 1, 0, 0),
+			want: []time.Time{
+				at(1997, 9, 2, 9, 0), at(1997, 9, 3, 9, 0), at(1997, 9, 4, 9, 0), at(1997, 9, 5, 9, 0), at(1997, 9, 6, 9, 0),
+				at(1997, 9, 7, 9, 0), at(1997, 9, 8, 9, 0), at(1997, 9, 9, 9, 0), at(1997, 9, 10, 9, 0), at(1997, 9, 11, 9, 0),
+				{},
+			},
+		},
+		"Should run every 10 days from the middle of the recurrence": {
+			recurrence: "DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=DAILY;INTERVAL=10",
+			prevStart:  at(1997, 12, 1, 9, 0),
+			want:       []time.Time{at(1997, 12, 11, 9, 0), at(1997, 12, 21, 9, 0)},
+		},
+		"Should keep the wall clock time across daylight saving time": {
+			recurrence: "DTSTART;TZID=America/New_York:19971024T090000\nRRULE:FREQ=DAILY",
+			prevStart:  at(1997, 10, 25, 9, 0),
+			want:       []time.Time{at(1997, 10, 26, 9, 0), at(1997, 10, 27, 9, 0)},
+		},
+		"Should run every other week on Tuesday and Thursday for 8 occurrences": {
+			recurrence: "DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=8;WKST=SU;BYDAY=TU,TH",
+			prevStart:  at(1997, 9, 1, 0, 0),
+			want: []time.Time{
+				at(1997, 9, 2, 9, 0), at(1997, 9, 4, 9, 0), at(1997, 9, 16, 9, 0), at(1997, 9, 18, 9, 0),
+				at(1997, 9, 30, 9, 0), at(1997, 10, 2, 9, 0), at(1997, 10, 14, 9, 0), at(1997, 10, 16, 9, 0),
+				{},
+			},
+		},
+		"Should run monthly on the first Friday for 10 occurrences": {
+			recurrence: "DTSTART;TZID=America/New_York:19970905T090000\nRRULE:FREQ=MONTHLY;COUNT=10;BYDAY=1FR",
+			prevStart:  at(1997, 9, 1, 0, 0),
+			want: []time.Time{
+				at(1997, 9, 5, 9, 0), at(1997, 10, 3, 9, 0), at(1997, 11, 7, 9, 0), at(1997, 12, 5, 9, 0), at(1998, 1, 2, 9, 0),
+				at(1998, 2, 6, 9, 0), at(1998, 3, 6, 9, 0), at(1998, 4, 3, 9, 0), at(1998, 5, 1, 9, 0), at(1998, 6, 5, 9, 0),
+				{},
+			},
+		},
+		"Should run monthly on the second to last Monday for 6 months": {
+			recurrence: "DTSTART;TZID=America/New_York:19970922T090000\nRRULE:FREQ=MONTHLY;COUNT=6;BYDAY=-2MO",
+			prevStart:  at(1997, 9, 1, 0, 0),
+			want: []time.Time{
+				at(1997, 9, 22, 9, 0), at(1997, 10, 20, 9, 0), at(1997, 11, 17, 9, 0),
+				at(1997, 12, 22, 9, 0), at(1998, 1, 19, 9, 0), at(1998, 2, 16, 9, 0),
+				{},
+			},
+		},
+		"Should run monthly on the third to last day": {
+			recurrence: "DTSTART;TZID=America/New_York:19970928T090000\nRRULE:FREQ=MONTHLY;BYMONTHDAY=-3",
+			prevStart:  at(1997, 9, 1, 0, 0),
+			want: []time.Time{
+				at(1997, 9, 28, 9, 0), at(1997, 10, 29, 9, 0), at(1997, 11, 28, 9, 0),
+				at(1997, 12, 29, 9, 0), at(1998, 1, 29, 9, 0), at(1998, 2, 26, 9, 0),
+			},
+		},
+		"Should run on the last work day of the month": {
+			recurrence: "DTSTART;TZID=America/New_York:19970929T090000\nRRULE:FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+			prevStart:  at(1997, 9, 1, 0, 0),
+			want: []time.Time{
+				at(1997, 9, 30, 9, 0), at(1997, 10, 31, 9, 0), at(1997, 11, 28, 9, 0),
+				at(1997, 12, 31, 9, 0), at(1998, 1, 30, 9, 0), at(1998, 2, 27, 9, 0),
+			},
+		},
+		"Should run every Friday the 13th except DTSTART": {
+			recurrence: "DTSTART;TZID=America/New_York:19970902T090000\n" +
+				"EXDATE;TZID=America/New_York:19970902T090000\n" +
+				"RRULE:FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13",
+			prevStart: at(1997, 9, 1, 0, 0),
+			want: []time.Time{
+				at(1998, 2, 13, 9, 0), at(1998, 3, 13, 9, 0), at(1998, 11, 13, 9, 0), at(1999, 8, 13, 9, 0), at(2000, 10, 13, 9, 0),
+			},
+		},
+		"Should run yearly in June and July for 10 occurrences": {
+			recurrence: "DTSTART;TZID=America/New_York:19970610T090000\nRRULE:FREQ=YEARLY;COUNT=10;BYMONTH=6,7",
+			prevStart:  at(2000, 7, 1, 0, 0),
+			want: []time.Time{
+				at(2000, 7, 10, 9, 0), at(2001, 6, 10, 9, 0), at(2001, 7, 10, 9, 0), {},
+			},
+		},
+		"Should run on Monday of week number 20": {
+			recurrence: "DTSTART;TZID=America/New_York:19970512T090000\nRRULE:FREQ=YEARLY;BYWEEKNO=20;BYDAY=MO",
+			prevStart:  at(1997, 5, 1, 0, 0),
+			want:       []time.Time{at(1997, 5, 12, 9, 0), at(1998, 5, 11, 9, 0), at(1999, 5, 17, 9, 0)},
+		},
+		"Should run on the 20th Monday of the year": {
+			recurrence: "DTSTART;TZID=America/New_York:19970519T090000\nRRULE:FREQ=YEARLY;BYDAY=20MO",
+			prevStart:  at(1997, 5, 1, 0, 0),
+			want:       []time.Time{at(1997, 5, 19, 9, 0), at(1998, 5, 18, 9, 0), at(1999, 5, 17, 9, 0)},
+		},
+		"Should run every 15 minutes for 6 occurrences": {
+			recurrence: "DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=MINUTELY;INTERVAL=15;COUNT=6",
+			prevStart:  at(1997, 9, 2, 9, 0),
+			want: []time.Time{
+				at(1997, 9, 2, 9, 15), at(1997, 9, 2, 9, 30), at(1997, 9, 2, 9, 45), at(1997, 9, 2, 10, 0), at(1997, 9, 2, 10, 15), {},
+			},
+		},
+		"Should run every 20 minutes from 9 to 16:40 every day": {
+			recurrence: "DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=DAILY;BYHOUR=9,10,11,12,13,14,15,16;BYMINUTE=0,20,40",
+			prevStart:  at(1997, 9, 2, 16, 30),
+			want:       []time.Time{at(1997, 9, 2, 16, 40), at(1997, 9, 3, 9, 0), at(1997, 9, 3, 9, 20)},
+		},
+		"Should run until a UTC time": {
+			recurrence: "DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=DAILY;UNTIL=19970904T130000Z",
+			prevStart:  at(1997, 9, 1, 0, 0),
+			want:       []time.Time{at(1997, 9, 2, 9, 0), at(1997, 9, 3, 9, 0), at(1997, 9, 4, 9, 0), {}},
+		},
+		"Should add RDATEs and remove EXDATEs": {
+			recurrence: "DTSTART:19970902T090000Z\n" +
+				"RRULE:FREQ=WEEKLY;COUNT=3\n" +
+				"RDATE:19970903T120000Z,19970905T120000Z\n" +
+				"EXDATE:19970909T090000Z,19970905T120000Z",
+			prevStart: time.Date(1997, 9, 1, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(1997, 9, 2, 9, 0, 0, 0, time.UTC),
+				time.Date(1997, 9, 3, 12, 0, 0, 0, time.UTC),
+				time.Date(1997, 9, 16, 9, 0, 0, 0, time.UTC),
+				{},
+			},
+		},
+		"Should run RDATEs without an RRULE": {
+			recurrence: "DTSTART;TZID=America/New_York:19970902T090000\nRDATE;TZID=America/New_York:19970902T090000,19971225T090000",
+			prevStart:  at(1997, 9, 1, 0, 0),
+			want:       []time.Time{at(1997, 9, 2, 9, 0), at(1997, 12, 25, 9, 0), {}},
+		},
+		"Should count occurrences further than the search limit from DTSTART": {
+			recurrence: "DTSTART:20100101T000000Z\nRRULE:FREQ=HOURLY;COUNT=200000",
+			prevStart:  time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2022, 6, 1, 1, 0, 0, 0, time.UTC),
+				time.Date(2022, 6, 1, 2, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should stop after the last counted occurrence far from DTSTART": {
+			recurrence: "DTSTART:20100101T000000Z\nRRULE:FREQ=HOURLY;COUNT=200000",
+			prevStart:  time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC).Add(199998 * time.Hour),
+			want:       []time.Time{time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC).Add(199999 * time.Hour), {}},
+		},
+		"Should evaluate a floating DTSTART in the location of the previous run": {
+			recurrence: "DTSTART:19970902T090000\nRRULE:FREQ=DAILY",
+			prevStart:  at(1997, 9, 2, 9, 0),
+			want:       []time.Time{at(1997, 9, 3, 9, 0)},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := Parse(tt.recurrence)
+			require.NoError(t, err)
+
+			prev := tt.prevStart
+			for _, want := range tt.want {
+				next := s.Next(prev)
+				require.True(t, want.Equal(next), "want %s got %s", want, next)
+
+				prev = next
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := map[string]string{
+		"Should require DTSTART":                    "RRULE:FREQ=DAILY",
+		"Should reject a repeated DTSTART":          "DTSTART:19970902T090000\nDTSTART:19970902T090000",
+		"Should reject an unknown TZID":             "DTSTART;TZID=Mars/Olympus_Mons:19970902T090000",
+		"Should reject a malformed DTSTART":         "DTSTART:1997-09-02",
+		"Should reject an unsupported property":     "DTSTART:19970902T090000\nRRULE:FREQ=DAILY\nDURATION:PT1H",
+		"Should require FREQ":                       "DTSTART:19970902T090000\nRRULE:COUNT=2",
+		"Should reject an unknown FREQ":             "DTSTART:19970902T090000\nRRULE:FREQ=FORTNIGHTLY",
+		"Should reject COUNT and UNTIL together":    "DTSTART:19970902T090000\nRRULE:FREQ=DAILY;COUNT=2;UNTIL=19971224T000000Z",
+		"Should reject an invalid INTERVAL":         "DTSTART:19970902T090000\nRRULE:FREQ=DAILY;INTERVAL=0",
+		"Should reject a value out of range":        "DTSTART:19970902T090000\nRRULE:FREQ=MONTHLY;BYMONTHDAY=32",
+		"Should reject an invalid weekday":          "DTSTART:19970902T090000\nRRULE:FREQ=MONTHLY;BYDAY=1XX",
+		"Should reject ordinals in a weekly rule":   "DTSTART:19970902T090000\nRRULE:FREQ=WEEKLY;BYDAY=1MO",
+		"Should reject BYWEEKNO in a monthly rule":  "DTSTART:19970902T090000\nRRULE:FREQ=MONTHLY;BYWEEKNO=1",
+		"Should reject an unsupported RRULE part":   "DTSTART:19970902T090000\nRRULE:FREQ=DAILY;BYEASTER=1",
+		"Should reject an unsupported RDATE period": "DTSTART:19970902T090000\nRDATE;VALUE=PERIOD:19970902T090000Z/PT1H",
+		"Should reject a malformed content line":    "DTSTART:19970902T090000\nnonsense",
+	}
+	for name, recurrence := range tests {
+		recurrence := recurrence
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Parse(recurrence)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, ErrInvalidRecurrence), err.Error())
+		})
+	}
+}
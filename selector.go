@@ -0,0 +1,105 @@
+package cronalt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ahmedalhulaibi/cronalt/job"
+)
+
+// selectStore is implemented by a jobStore which selects jobs by their Metadata itself, e.g. job.store
+type selectStore interface {
+	Select(sel job.Selector) []job.Config
+}
+
+// Select returns a snapshot of the status of the jobs matched by sel sorted by name, see Status
+// e.g. s.Select(job.ByOwner("billing")) for the jobs scheduled with WithMetadata(job.Metadata{Owner: "billing"})
+func (s *Scheduler) Select(sel job.Selector) []JobStatus {
+	return s.statusOf(s.selectJobs(sel))
+}
+
+// selectJobs returns the jobs matched by sel, falling back to filtering every job when the store cannot select them
+func (s *Scheduler) selectJobs(sel job.Selector) []job.Config {
+	if ss, ok := s.jobs.(selectStore); ok {
+		return ss.Select(sel)
+	}
+
+	var selected []job.Config
+
+	for _, cfg := range s.jobs.GetAll() {
+		if sel(job.MetadataOf(cfg)) {
+			selected = append(selected, cfg)
+		}
+	}
+
+	return selected
+}
+
+// forSelected calls fn with the name of every job matched by sel in order of name
+// It returns the names fn succeeded for and the first error, wrapped with the name of its job, after trying every job
+func (s *Scheduler) forSelected(sel job.Selector, fn func(name string) error) ([]string, error) {
+	cfgs := s.selectJobs(sel)
+
+	names := make([]string, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		names = append(names, cfg.Job().Name())
+	}
+
+	sort.Strings(names)
+
+	var (
+		done     []string
+		firstErr error
+	)
+
+	for _, name := range names {
+		if err := fn(name); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%w:%s", err, name)
+			}
+
+			continue
+		}
+
+		done = append(done, name)
+	}
+
+	return done, firstErr
+}
+
+// PauseSelected pauses every job matched by sel and returns the names of the paused jobs, see Pause
+func (s *Scheduler) PauseSelected(sel job.Selector) ([]string, error) {
+	return s.forSelected(sel, s.Pause)
+}
+
+// ResumeSelected resumes every job matched by sel and returns the names of the resumed jobs, see Resume
+func (s *Scheduler) ResumeSelected(sel job.Selector, opts ...ResumeOption) ([]string, error) {
+	return s.forSelected(sel, func(name string) error {
+		return s.Resume(name, opts...)
+	})
+}
+
+// TriggerSelected triggers every job matched by sel and returns the names of the triggered jobs, see Trigger
+// With TriggerWait the runs are waited for one after the other, a job whose run failed is still among the triggered jobs
+// and the first error of a run is returned
+func (s *Scheduler) TriggerSelected(ctx context.Context, sel job.Selector, opts ...TriggerOption) ([]string, error) {
+	var triggered []string
+
+	_, err := s.forSelected(sel, func(name string) error {
+		err := s.Trigger(ctx, name, opts...)
+		if !errors.Is(err, job.ErrJobDoesNotExist) {
+			triggered = append(triggered, name)
+		}
+
+		return err
+	})
+
+	return triggered, err
+}
+
+// RemoveSelected removes every job matched by sel and returns the names of the removed jobs, see Remove
+func (s *Scheduler) RemoveSelected(sel job.Selector) ([]string, error) {
+	return s.forSelected(sel, s.Remove)
+}
@@ -0,0 +1,146 @@
+package cronalt
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ahmedalhulaibi/cronalt/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// plainStore is a jobStore which cannot select jobs itself
+type plainStore struct {
+	jobStore
+}
+
+func newSelectorFixture(t *testing.T, opts ...SchedulerOption) *Scheduler {
+	s, err := NewScheduler(1, opts...)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Schedule(Every(time.Hour), renamedJob{Job: errJob{}, name: "invoice"}, WithMetadata(job.Metadata{
+		Tags:   []string{"billing", "nightly"},
+		Owner:  "billing-team",
+		Labels: map[string]string{"tier": "critical"},
+	})))
+	require.NoError(t, s.Schedule(Every(time.Hour), renamedJob{Job: errJob{}, name: "refund"}, WithTags("billing"), WithMetadata(job.Metadata{
+		Owner:       "billing-team",
+		Description: "Processes refunds",
+		Labels:      map[string]string{"tier": "standard"},
+	})))
+	require.NoError(t, s.Schedule(Every(time.Hour), renamedJob{Job: errJob{}, name: "report"}, WithTags("nightly")))
+	require.NoError(t, s.Schedule(Every(time.Hour), renamedJob{Job: errJob{}, name: "untagged"}))
+
+	return s
+}
+
+func names(statuses []JobStatus) []string {
+	var n []string
+	for _, js := range statuses {
+		n = append(n, js.Name)
+	}
+
+	return n
+}
+
+func TestScheduler_Select(t *testing.T) {
+	tests := map[string]struct {
+		opts []SchedulerOption
+		sel  job.Selector
+		want []string
+	}{
+		"Should select the jobs with a tag":              {sel: job.ByTag("billing"), want: []string{"invoice", "refund"}},
+		"Should select the jobs with all of the tags":    {sel: job.ByTag("billing", "nightly"), want: []string{"invoice"}},
+		"Should select the jobs of an owner":             {sel: job.ByOwner("billing-team"), want: []string{"invoice", "refund"}},
+		"Should select the jobs with a label":            {sel: job.ByLabel("tier", "standard"), want: []string{"refund"}},
+		"Should select the jobs matched by all":          {sel: job.MatchAll(job.ByTag("nightly"), job.ByLabel("tier", "critical")), want: []string{"invoice"}},
+		"Should select no jobs when none match":          {sel: job.ByOwner("nobody")},
+		"Should select every job with an empty selector": {sel: job.MatchAll(), want: []string{"invoice", "refund", "report", "untagged"}},
+		"Should select jobs from a store which cannot select them": {
+			opts: []SchedulerOption{WithJobStore(plainStore{job.NewStore()})},
+			sel:  job.ByTag("nightly"),
+			want: []string{"invoice", "report"},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := newSelectorFixture(t, tt.opts...)
+			assert.Equal(t, tt.want, names(s.Select(tt.sel)))
+		})
+	}
+	t.Run("Should report the metadata of a job", func(t *testing.T) {
+		s := newSelectorFixture(t)
+
+		statuses := s.Select(job.ByLabel("tier", "standard"))
+		require.Len(t, statuses, 1)
+		assert.Equal(t, job.Metadata{
+			Tags:        []string{"billing"},
+			Owner:       "billing-team",
+			Description: "Processes refunds",
+			Labels:      map[string]string{"tier": "standard"},
+		}, statuses[0].Metadata)
+	})
+	t.Run("Should not let callers change the metadata of a job", func(t *testing.T) {
+		s := newSelectorFixture(t)
+
+		m := s.Select(job.ByLabel("tier", "critical"))[0].Metadata
+		m.Tags[0] = "changed"
+		m.Labels["tier"] = "changed"
+
+		assert.Equal(t, []string{"invoice", "refund"}, names(s.Select(job.ByTag("billing"))))
+		assert.Equal(t, []string{"invoice"}, names(s.Select(job.ByLabel("tier", "critical"))))
+	})
+}
+
+func TestScheduler_BulkOperations(t *testing.T) {
+	t.Run("Should pause and resume the selected jobs", func(t *testing.T) {
+		s := newSelectorFixture(t)
+
+		paused, err := s.PauseSelected(job.ByTag("billing"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"invoice", "refund"}, paused)
+
+		for _, js := range s.Status() {
+			assert.Equal(t, js.Name == "invoice" || js.Name == "refund", js.State == StatePaused, js.Name)
+		}
+
+		resumed, err := s.ResumeSelected(job.ByOwner("billing-team"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"invoice", "refund"}, resumed)
+		assert.Empty(t, s.Select(func(m job.Metadata) bool { return false }))
+
+		for _, js := range s.Status() {
+			assert.NotEqual(t, StatePaused, js.State, js.Name)
+		}
+	})
+	t.Run("Should trigger the selected jobs and report the first error of a run", func(t *testing.T) {
+		s, err := NewScheduler(1)
+		require.NoError(t, err)
+
+		require.NoError(t, s.Schedule(Every(time.Hour), renamedJob{Job: errJob{}, name: "a"}, WithTags("batch")))
+		require.NoError(t, s.Schedule(Every(time.Hour), renamedJob{Job: errJob{err: fmt.Errorf("custom_err")}, name: "b"}, WithTags("batch")))
+		require.NoError(t, s.Schedule(Every(time.Hour), renamedJob{Job: errJob{}, name: "c"}, WithTags("batch")))
+
+		triggered, err := s.TriggerSelected(context.Background(), job.ByTag("batch"), TriggerWait())
+		require.EqualError(t, err, "custom_err:b")
+		assert.Equal(t, []string{"a", "b", "c"}, triggered)
+
+		for _, js := range s.Status() {
+			assert.False(t, js.LastEnd.IsZero(), js.Name)
+		}
+	})
+	t.Run("Should remove the selected jobs", func(t *testing.T) {
+		s := newSelectorFixture(t)
+
+		removed, err := s.RemoveSelected(job.ByTag("nightly"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"invoice", "report"}, removed)
+		assert.Equal(t, []string{"refund", "untagged"}, names(s.Status()))
+	})
+}
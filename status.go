@@ -0,0 +1,211 @@
+package cronalt
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ahmedalhulaibi/cronalt/job"
+)
+
+// JobState is the current state of a job
+type JobState int
+
+const (
+	// StateIdle is a job waiting for its next scheduled run
+	StateIdle JobState = iota
+	// StateQueued is a job with a run waiting for a slot in the job pool
+	StateQueued
+	// StateRunning is a job with a run in progress
+	StateRunning
+	// StatePaused is a paused job, see Scheduler.Pause
+	StatePaused
+	// StateFinished is a job whose timer has no further runs, it stays registered until it is removed
+	StateFinished
+)
+
+func (js JobState) String() string {
+	switch js {
+	case StateQueued:
+		return "queued"
+	case StateRunning:
+		return "running"
+	case StatePaused:
+		return "paused"
+	case StateFinished:
+		return "finished"
+	default:
+		return "idle"
+	}
+}
+
+// JobStatus is a snapshot of what a job is doing and how its last run went
+type JobStatus struct {
+	Name  string
+	State JobState
+	// LastStart and LastEnd are when the last run started and ended
+	LastStart time.Time
+	LastEnd   time.Time
+	// LastErr is the error returned by the last run
+	LastErr error
+	// LastDuration is how long the last run took
+	LastDuration time.Duration
+	// NextRun is the scheduled time of the next run, it is zero when the job loop is not running
+	NextRun time.Time
+	// ConsecutiveFailures counts the runs which returned an error since the last successful run
+	ConsecutiveFailures int
+	// Metadata is a copy of the Metadata the job was scheduled with, see WithMetadata
+	Metadata job.Metadata
+}
+
+// Status returns a snapshot of the status of every registered job sorted by name
+// It is safe to call concurrently, e.g. from an HTTP handler
+func (s *Scheduler) Status() []JobStatus {
+	return s.statusOf(s.jobs.GetAll())
+}
+
+// statusOf returns a snapshot of the status of the jobs of cfgs sorted by name
+func (s *Scheduler) statusOf(cfgs []job.Config) []JobStatus {
+	s.mu.Lock()
+	started := s.ctx != nil
+	s.mu.Unlock()
+
+	statuses := make([]JobStatus, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		name := cfg.Job().Name()
+
+		js := s.statuses.get(name)
+		if !started {
+			js.NextRun = time.Time{}
+		}
+
+		if s.isPaused(name) {
+			js.State = StatePaused
+		}
+
+		// Copied so callers cannot change the Metadata of the registered job
+		js.Metadata = job.MetadataOf(cfg).Copy()
+
+		statuses = append(statuses, js)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	return statuses
+}
+
+// runStatus is the status of a job along with the number of its in-flight runs
+type runStatus struct {
+	JobStatus
+	queued  int
+	running int
+	// finished is true once the timer of the job has no further runs
+	finished bool
+	// removed is true once the job is removed, its status is dropped when it has no queued or running runs left
+	removed bool
+}
+
+// statusBoard tracks the status of jobs keyed by job name
+type statusBoard struct {
+	sync.Mutex
+	jobs map[string]*runStatus
+}
+
+func newStatusBoard() *statusBoard {
+	return &statusBoard{
+		jobs: make(map[string]*runStatus),
+	}
+}
+
+// update calls fn with the status of a registered job
+func (b *statusBoard) update(name string, fn func(rs *runStatus)) {
+	b.Lock()
+	defer b.Unlock()
+
+	rs, ok := b.jobs[name]
+	if !ok {
+		return
+	}
+
+	fn(rs)
+	b.prune(name, rs)
+}
+
+// reset clears the history of a job which is registered, possibly again, while keeping track of its in-flight runs
+func (b *statusBoard) reset(name string) {
+	b.Lock()
+	defer b.Unlock()
+
+	rs, ok := b.jobs[name]
+	if !ok {
+		rs = &runStatus{}
+		b.jobs[name] = rs
+	}
+
+	rs.JobStatus = JobStatus{Name: name}
+	rs.finished = false
+	rs.removed = false
+}
+
+// remove drops the status of a removed job once it has no queued or running runs left
+func (b *statusBoard) remove(name string) {
+	b.Lock()
+	defer b.Unlock()
+
+	if rs, ok := b.jobs[name]; ok {
+		rs.removed = true
+		b.prune(name, rs)
+	}
+}
+
+// prune drops the status of a removed job without queued or running runs, it is called with b locked
+func (b *statusBoard) prune(name string, rs *runStatus) {
+	if rs.removed && rs.queued == 0 && rs.running == 0 {
+		delete(b.jobs, name)
+	}
+}
+
+// get returns the status of a job
+func (b *statusBoard) get(name string) JobStatus {
+	b.Lock()
+	defer b.Unlock()
+
+	rs, ok := b.jobs[name]
+	if !ok {
+		return JobStatus{Name: name}
+	}
+
+	js := rs.JobStatus
+
+	switch {
+	case rs.running > 0:
+		js.State = StateRunning
+	case rs.queued > 0:
+		js.State = StateQueued
+	case rs.finished:
+		js.State = StateFinished
+	default:
+		js.State = StateIdle
+	}
+
+	return js
+}
+
+// inflight returns the sorted names of jobs with queued or running runs
+func (b *statusBoard) inflight() []string {
+	b.Lock()
+	defer b.Unlock()
+
+	names := make([]string, 0, len(b.jobs))
+	for name, rs := range b.jobs {
+		if rs.queued > 0 || rs.running > 0 {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
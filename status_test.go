@@ -0,0 +1,128 @@
+package cronalt
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_Status(t *testing.T) {
+	t.Run("Should report the last run and consecutive failures of each job", func(t *testing.T) {
+		s, err := NewScheduler(1)
+		require.NoError(t, err)
+		require.NoError(t, s.Schedule(Every(time.Hour), errJob{err: fmt.Errorf("custom_err")}))
+		require.NoError(t, s.Schedule(Every(time.Hour), panicJob{}))
+
+		for i := 0; i < 2; i++ {
+			require.Error(t, s.Trigger(context.Background(), "errjob", TriggerWait()))
+		}
+
+		require.NoError(t, s.Pause("panicker"))
+
+		statuses := s.Status()
+		require.Len(t, statuses, 2)
+
+		assert.Equal(t, "errjob", statuses[0].Name)
+		assert.Equal(t, StateIdle, statuses[0].State)
+		assert.EqualError(t, statuses[0].LastErr, "custom_err")
+		assert.Equal(t, 2, statuses[0].ConsecutiveFailures)
+		assert.False(t, statuses[0].LastStart.IsZero())
+		assert.False(t, statuses[0].LastEnd.Before(statuses[0].LastStart))
+
+		assert.Equal(t, "panicker", statuses[1].Name)
+		assert.Equal(t, StatePaused, statuses[1].State)
+		assert.True(t, statuses[1].LastStart.IsZero())
+	})
+}
+
+func TestScheduler_RemoveStatus(t *testing.T) {
+	t.Run("Should drop the status of removed jobs", func(t *testing.T) {
+		s, err := NewScheduler(1)
+		require.NoError(t, err)
+
+		for i := 0; i < 1000; i++ {
+			j := signalJob{name: fmt.Sprint("tenant-", i)}
+			require.NoError(t, s.Schedule(Every(time.Hour), j))
+			require.NoError(t, s.Remove(j.Name()))
+		}
+
+		assert.Empty(t, s.Status())
+		assert.Empty(t, s.statuses.jobs)
+	})
+	t.Run("Should drop the status of a removed job once its run completes", func(t *testing.T) {
+		s, err := NewScheduler(1)
+		require.NoError(t, err)
+		require.NoError(t, s.Schedule(Every(time.Hour), sleepJob{d: 20 * time.Millisecond}))
+
+		triggered := make(chan empty)
+		go func() {
+			assert.NoError(t, s.Trigger(context.Background(), "sleeper", TriggerWait()))
+			close(triggered)
+		}()
+		require.Eventually(t, func() bool { return s.Status()[0].State == StateRunning }, time.Second, time.Millisecond)
+
+		require.NoError(t, s.Remove("sleeper"))
+		assert.Equal(t, []string{"sleeper"}, s.statuses.inflight())
+
+		<-triggered
+		assert.Empty(t, s.statuses.jobs)
+	})
+}
+
+type finishedListener struct {
+	NoopListener
+	finished chan string
+}
+
+func (fl finishedListener) OnFinished(_ context.Context, e Event) {
+	fl.finished <- e.Job
+}
+
+func TestScheduler_Finished(t *testing.T) {
+	tests := map[string]struct {
+		opts []SchedulerOption
+	}{
+		"Should stop a job whose timer has no further runs": {},
+		"Should stop a job whose timer has no further runs with the heap dispatcher": {
+			opts: []SchedulerOption{WithHeapDispatcher()},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var count int64
+			fl := finishedListener{finished: make(chan string, 1)}
+
+			s, err := NewScheduler(10, append(tt.opts, WithListener(fl))...)
+			require.NoError(t, err)
+			require.NoError(t, s.Schedule(Times(3, Every(time.Millisecond)), countingJob{name: "bounded", count: &count, done: make(chan empty), total: 3}))
+
+			done := startScheduler(t, s)
+
+			select {
+			case name := <-fl.finished:
+				assert.Equal(t, "bounded", name)
+			case <-time.After(5 * time.Second):
+				require.Fail(t, "job did not finish")
+			}
+
+			require.Eventually(t, func() bool {
+				return s.Status()[0].State == StateFinished
+			}, time.Second, time.Millisecond)
+
+			assert.Equal(t, int64(3), atomic.LoadInt64(&count))
+			assert.True(t, s.Status()[0].NextRun.IsZero())
+
+			require.NoError(t, s.Shutdown(context.Background()))
+			<-done
+		})
+	}
+}
@@ -1,12 +1,19 @@
 package cronalt
 
 import (
+	"fmt"
+	"sync"
 	"time"
 
+	"github.com/ahmedalhulaibi/cronalt/cron"
+	"github.com/ahmedalhulaibi/cronalt/internal/wallclock"
 	"github.com/ahmedalhulaibi/cronalt/job"
 	"github.com/gorhill/cronexpr"
 )
 
+// ErrInvalidInterval is returned when scheduling a timer whose interval is not positive
+var ErrInvalidInterval error = fmt.Errorf("invalid interval")
+
 // jobTimer acts as a scheduler for a specific job
 // given the prev start time of the job and current time
 // the jobTimer implementation should return duration to wait until running again
@@ -28,4 +35,207 @@ func Every(d time.Duration) durationTimer {
 	return durationTimer{d}
 }
 
-var _ jobTimer = (*cronexpr.Expression)(nil)
+type alignedTimer struct {
+	d, offset time.Duration
+}
+
+var (
+	_ jobTimer  = (*alignedTimer)(nil)
+	_ validator = (*alignedTimer)(nil)
+)
+
+// EveryAligned returns a timer which runs on multiples of d on the wall clock plus offset regardless of when it started
+// e.g. EveryAligned(15*time.Minute, 0) runs at :00, :15, :30 and :45 of every hour
+// Intervals which divide an hour are aligned on absolute time so they keep their spacing across daylight saving time transitions
+// Other intervals which divide a day are aligned to midnight in the location of the previous run
+// and behave like a cron expression around daylight saving time transitions, see CronIn
+func EveryAligned(d, offset time.Duration) alignedTimer {
+	if d > 0 {
+		offset %= d
+		if offset < 0 {
+			offset += d
+		}
+	}
+
+	return alignedTimer{d: d, offset: offset}
+}
+
+// Err returns ErrInvalidInterval when d is not positive
+func (a alignedTimer) Err() error {
+	if a.d <= 0 {
+		return fmt.Errorf("%w:%s", ErrInvalidInterval, a.d)
+	}
+
+	return nil
+}
+
+func (a alignedTimer) Next(prevStart time.Time) time.Time {
+	if a.d <= 0 {
+		return time.Time{}
+	}
+
+	if time.Hour%a.d == 0 {
+		// Shift by the part of the zone offset below an hour, e.g. +05:30, so runs stay on the hour of the wall clock
+		_, zoneOffset := prevStart.Zone()
+		shift := time.Duration(zoneOffset) * time.Second % time.Hour
+
+		return prevStart.Add(shift - a.offset).Truncate(a.d).Add(a.d + a.offset - shift)
+	}
+
+	return wallclock.In(alignedWallTimer(a), prevStart.Location()).Next(prevStart)
+}
+
+// alignedWallTimer aligns wall clock times, which are in UTC so Truncate aligns them to midnight
+type alignedWallTimer alignedTimer
+
+func (a alignedWallTimer) Next(w time.Time) time.Time {
+	return w.Add(-a.offset).Truncate(a.d).Add(a.d + a.offset)
+}
+
+var (
+	_ jobTimer = (*cronexpr.Expression)(nil)
+	_ jobTimer = (*cron.Schedule)(nil)
+)
+
+// CronIn parses a cron expression, see package cron, evaluated on the wall clock of the IANA time zone tz, e.g. "America/Toronto"
+// Around daylight saving time transitions a wall clock time skipped by a gap fires when the gap ends
+// and a wall clock time repeated by an overlap fires once
+func CronIn(expr, tz string) (job.Timer, error) {
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, err
+	}
+
+	return cron.ParseInLocation(expr, loc)
+}
+
+type onceTimer struct {
+	at time.Time
+}
+
+var _ jobTimer = (*onceTimer)(nil)
+
+// Once returns a timer which runs once at t, a time which passed before the job is scheduled never runs
+func Once(t time.Time) onceTimer {
+	return onceTimer{at: t}
+}
+
+func (o onceTimer) Next(prevStart time.Time) time.Time {
+	if prevStart.Before(o.at) {
+		return o.at
+	}
+
+	return time.Time{}
+}
+
+type timesTimer struct {
+	inner job.Timer
+	n     int
+
+	mu sync.Mutex
+	// count is the number of runs fired so far
+	count int
+}
+
+var (
+	_ jobTimer         = (*timesTimer)(nil)
+	_ job.Binder       = (*timesTimer)(nil)
+	_ job.FireObserver = (*timesTimer)(nil)
+	_ validator        = (*timesTimer)(nil)
+)
+
+// Times returns a timer which stops after n runs of t
+// Runs count as they fire, occurrences skipped by a MisfirePolicy or missed while the job is paused do not count
+func Times(n int, t job.Timer) *timesTimer {
+	return &timesTimer{inner: t, n: n}
+}
+
+// Bind returns a timer for j with its own count, binding t to j as well
+func (tt *timesTimer) Bind(j job.Job) job.Timer {
+	inner := tt.inner
+	if b, ok := inner.(job.Binder); ok {
+		inner = b.Bind(j)
+	}
+
+	return Times(tt.n, inner)
+}
+
+// Err returns the error of t when it is built in steps, e.g. DayTimer
+func (tt *timesTimer) Err() error {
+	return timersErr(tt.inner)
+}
+
+func (tt *timesTimer) Next(prevStart time.Time) time.Time {
+	tt.mu.Lock()
+	done := tt.count >= tt.n
+	tt.mu.Unlock()
+
+	if done {
+		return time.Time{}
+	}
+
+	return tt.inner.Next(prevStart)
+}
+
+// Fired counts a run of the job
+func (tt *timesTimer) Fired(at time.Time) {
+	tt.mu.Lock()
+	tt.count++
+	tt.mu.Unlock()
+
+	fired(tt.inner, at)
+}
+
+type boundedTimer struct {
+	inner          job.Timer
+	startAt, endAt time.Time
+}
+
+var (
+	_ jobTimer         = (*boundedTimer)(nil)
+	_ job.Binder       = (*boundedTimer)(nil)
+	_ job.FireObserver = (*boundedTimer)(nil)
+	_ validator        = (*boundedTimer)(nil)
+)
+
+// Bounded returns a timer which only runs the occurrences of t between startAt and endAt inclusive
+// A zero startAt or endAt leaves that side unbounded
+func Bounded(t job.Timer, startAt, endAt time.Time) boundedTimer {
+	return boundedTimer{inner: t, startAt: startAt, endAt: endAt}
+}
+
+// Bind binds t to j
+func (b boundedTimer) Bind(j job.Job) job.Timer {
+	if binder, ok := b.inner.(job.Binder); ok {
+		b.inner = binder.Bind(j)
+	}
+
+	return b
+}
+
+// Err returns the error of t when it is built in steps, e.g. DayTimer
+func (b boundedTimer) Err() error {
+	return timersErr(b.inner)
+}
+
+func (b boundedTimer) Next(prevStart time.Time) time.Time {
+	next := stepUntil(b.inner, b.inner.Next(prevStart), b.startAt)
+
+	if !b.endAt.IsZero() && next.After(b.endAt) {
+		return time.Time{}
+	}
+
+	return next
+}
+
+// Fired passes a run of the job on to t
+func (b boundedTimer) Fired(at time.Time) {
+	fired(b.inner, at)
+}
+
+// fired tells t that the run scheduled at at fired if t keeps track of the runs of its job
+func fired(t job.Timer, at time.Time) {
+	if o, ok := t.(job.FireObserver); ok {
+		o.Fired(at)
+	}
+}
@@ -1,10 +1,14 @@
 package cronalt
 
 import (
+	"errors"
 	"testing"
 	"time"
+	_ "time/tzdata"
 
+	"github.com/ahmedalhulaibi/cronalt/job"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_durationTimer_Next(t *testing.T) {
@@ -43,3 +47,311 @@ func Test_durationTimer_Next(t *testing.T) {
 		})
 	}
 }
+
+func TestCronIn(t *testing.T) {
+	toronto, err := time.LoadLocation("America/Toronto")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		expr      string
+		prevStart time.Time
+		want      []time.Time
+	}{
+		"Should run at the wall clock time of the location": {
+			expr:      "0 2 * * *",
+			prevStart: time.Date(2021, 01, 01, 12, 0, 0, 0, toronto),
+			want: []time.Time{
+				time.Date(2021, 01, 02, 2, 0, 0, 0, toronto),
+				time.Date(2021, 01, 03, 2, 0, 0, 0, toronto),
+			},
+		},
+		"Should evaluate in the location regardless of the location of prevStart": {
+			expr:      "0 2 * * *",
+			prevStart: time.Date(2021, 01, 01, 17, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 01, 02, 7, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should run a time skipped by the spring forward gap when the gap ends": {
+			expr:      "30 2 * * *",
+			prevStart: time.Date(2021, 03, 13, 12, 0, 0, 0, toronto),
+			want: []time.Time{
+				time.Date(2021, 03, 14, 7, 0, 0, 0, time.UTC),
+				time.Date(2021, 03, 15, 6, 30, 0, 0, time.UTC),
+			},
+		},
+		"Should run times skipped by the spring forward gap once": {
+			expr:      "*/20 * * * *",
+			prevStart: time.Date(2021, 03, 14, 6, 40, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 03, 14, 7, 0, 0, 0, time.UTC),
+				time.Date(2021, 03, 14, 7, 20, 0, 0, time.UTC),
+			},
+		},
+		"Should run a time repeated by the fall back overlap once": {
+			expr:      "30 1 * * *",
+			prevStart: time.Date(2021, 11, 06, 12, 0, 0, 0, toronto),
+			want: []time.Time{
+				time.Date(2021, 11, 07, 5, 30, 0, 0, time.UTC),
+				time.Date(2021, 11, 8, 6, 30, 0, 0, time.UTC),
+			},
+		},
+		"Should continue in the repeated hour when started during it": {
+			expr:      "*/20 * * * *",
+			prevStart: time.Date(2021, 11, 07, 6, 10, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 11, 07, 6, 20, 0, 0, time.UTC),
+				time.Date(2021, 11, 07, 6, 40, 0, 0, time.UTC),
+				time.Date(2021, 11, 07, 7, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			timer, err := CronIn(tt.expr, "America/Toronto")
+			require.NoError(t, err)
+
+			prev := tt.prevStart
+			for _, want := range tt.want {
+				prev = timer.Next(prev)
+				assert.True(t, want.Equal(prev), "want %s got %s", want.UTC(), prev.UTC())
+			}
+		})
+	}
+}
+
+func TestCronIn_Errors(t *testing.T) {
+	_, err := CronIn("0 2 * * *", "Mars/Olympus_Mons")
+	require.Error(t, err)
+
+	_, err = CronIn("not a cron expression", "America/Toronto")
+	require.Error(t, err)
+}
+
+func TestEveryAligned(t *testing.T) {
+	toronto, err := time.LoadLocation("America/Toronto")
+	require.NoError(t, err)
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		timer     alignedTimer
+		prevStart time.Time
+		want      []time.Time
+	}{
+		"Should run on quarter hours regardless of start time": {
+			timer:     EveryAligned(15*time.Minute, 0),
+			prevStart: time.Date(2021, 01, 01, 9, 7, 42, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 01, 01, 9, 15, 0, 0, time.UTC),
+				time.Date(2021, 01, 01, 9, 30, 0, 0, time.UTC),
+				time.Date(2021, 01, 01, 9, 45, 0, 0, time.UTC),
+				time.Date(2021, 01, 01, 10, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should run after an aligned previous run": {
+			timer:     EveryAligned(15*time.Minute, 0),
+			prevStart: time.Date(2021, 01, 01, 9, 15, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 01, 01, 9, 30, 0, 0, time.UTC),
+			},
+		},
+		"Should shift the alignment by the offset": {
+			timer:     EveryAligned(15*time.Minute, 5*time.Minute),
+			prevStart: time.Date(2021, 01, 01, 9, 7, 42, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 01, 01, 9, 20, 0, 0, time.UTC),
+				time.Date(2021, 01, 01, 9, 35, 0, 0, time.UTC),
+			},
+		},
+		"Should wrap an offset larger than the interval": {
+			timer:     EveryAligned(time.Hour, 90*time.Minute),
+			prevStart: time.Date(2021, 01, 01, 9, 7, 42, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2021, 01, 01, 9, 30, 0, 0, time.UTC),
+				time.Date(2021, 01, 01, 10, 30, 0, 0, time.UTC),
+			},
+		},
+		"Should keep quarter hours across the end of daylight saving time": {
+			timer:     EveryAligned(15*time.Minute, 0),
+			prevStart: time.Date(2021, 11, 07, 5, 45, 0, 0, time.UTC).In(toronto),
+			want: []time.Time{
+				time.Date(2021, 11, 07, 6, 0, 0, 0, time.UTC),
+				time.Date(2021, 11, 07, 6, 15, 0, 0, time.UTC),
+			},
+		},
+		"Should run on the hour of a zone offset by half an hour": {
+			timer:     EveryAligned(time.Hour, 0),
+			prevStart: time.Date(2021, 01, 01, 9, 7, 0, 0, kolkata),
+			want: []time.Time{
+				time.Date(2021, 01, 01, 10, 0, 0, 0, kolkata),
+				time.Date(2021, 01, 01, 11, 0, 0, 0, kolkata),
+			},
+		},
+		"Should align to the wall clock of the location of the previous run": {
+			timer:     EveryAligned(24*time.Hour, 9*time.Hour),
+			prevStart: time.Date(2021, 03, 13, 12, 0, 0, 0, toronto),
+			want: []time.Time{
+				time.Date(2021, 03, 14, 9, 0, 0, 0, toronto),
+				time.Date(2021, 03, 15, 9, 0, 0, 0, toronto),
+			},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			prev := tt.prevStart
+			for _, want := range tt.want {
+				prev = tt.timer.Next(prev)
+				assert.True(t, want.Equal(prev), "want %s got %s", want, prev)
+			}
+		})
+	}
+}
+
+func TestEveryAligned_Err(t *testing.T) {
+	tests := map[string]time.Duration{
+		"Should reject a zero interval":     0,
+		"Should reject a negative interval": -time.Minute,
+	}
+	for name, d := range tests {
+		d := d
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := NewScheduler(1)
+			require.NoError(t, err)
+			assert.True(t, errors.Is(s.Schedule(EveryAligned(d, 0), signalJob{name: "invalid"}), ErrInvalidInterval))
+		})
+	}
+}
+
+func TestOnce(t *testing.T) {
+	at := time.Date(2021, 01, 01, 9, 0, 0, 0, time.UTC)
+	timer := Once(at)
+
+	assert.Equal(t, at, timer.Next(at.Add(-time.Hour)))
+	assert.True(t, timer.Next(at).IsZero())
+	assert.True(t, timer.Next(at.Add(time.Hour)).IsZero())
+}
+
+func TestTimes(t *testing.T) {
+	startFixture := time.Date(2021, 01, 01, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Should stop after n runs", func(t *testing.T) {
+		t.Parallel()
+
+		timer := Times(2, Every(time.Minute))
+
+		first := timer.Next(startFixture)
+		assert.Equal(t, startFixture.Add(time.Minute), first)
+		assert.Equal(t, startFixture.Add(2*time.Minute), timer.Next(first), "an occurrence which did not fire is not counted")
+
+		timer.Fired(first)
+		second := timer.Next(first)
+		assert.Equal(t, startFixture.Add(2*time.Minute), second)
+
+		timer.Fired(second)
+		assert.True(t, timer.Next(second).IsZero())
+	})
+
+	t.Run("Should count the runs of each bound job separately", func(t *testing.T) {
+		t.Parallel()
+
+		timer := Times(1, Every(time.Minute))
+
+		a := timer.Bind(signalJob{name: "a"})
+		b := timer.Bind(signalJob{name: "b"})
+
+		fired(a, a.Next(startFixture))
+		assert.True(t, a.Next(startFixture.Add(time.Minute)).IsZero())
+		assert.False(t, b.Next(startFixture).IsZero())
+	})
+
+	t.Run("Should count the runs of a wrapped timer", func(t *testing.T) {
+		t.Parallel()
+
+		timer := Bounded(Times(1, Every(time.Minute)), time.Time{}, time.Time{})
+
+		fired(timer, timer.Next(startFixture))
+		assert.True(t, timer.Next(startFixture.Add(time.Minute)).IsZero())
+	})
+}
+
+func TestBounded(t *testing.T) {
+	startFixture := time.Date(2021, 01, 01, 0, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		timer boundedTimer
+		want  []time.Time
+	}{
+		"Should skip the occurrences before startAt": {
+			timer: Bounded(Every(10*time.Minute), startFixture.Add(25*time.Minute), time.Time{}),
+			want: []time.Time{
+				startFixture.Add(30 * time.Minute),
+				startFixture.Add(40 * time.Minute),
+			},
+		},
+		"Should jump to startAt when it is far away": {
+			timer: Bounded(EveryAligned(time.Minute, 0), startFixture.AddDate(1, 0, 0), time.Time{}),
+			want: []time.Time{
+				startFixture.AddDate(1, 0, 0),
+				startFixture.AddDate(1, 0, 0).Add(time.Minute),
+			},
+		},
+		"Should stop after endAt": {
+			timer: Bounded(Every(10*time.Minute), time.Time{}, startFixture.Add(20*time.Minute)),
+			want: []time.Time{
+				startFixture.Add(10 * time.Minute),
+				startFixture.Add(20 * time.Minute),
+				{},
+			},
+		},
+		"Should stop when the inner timer stops": {
+			timer: Bounded(Once(startFixture.Add(time.Minute)), time.Time{}, startFixture.Add(time.Hour)),
+			want: []time.Time{
+				startFixture.Add(time.Minute),
+				{},
+			},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			prev := startFixture
+			for _, want := range tt.want {
+				prev = tt.timer.Next(prev)
+				assert.True(t, want.Equal(prev), "want %s got %s", want, prev)
+			}
+		})
+	}
+}
+
+func TestBoundedTimers_Err(t *testing.T) {
+	tests := map[string]job.Timer{
+		"Should reject n runs of an invalid timer":     Times(3, Daily().At("25:00")),
+		"Should reject the bounds of an invalid timer": Bounded(Weekly(), time.Time{}, time.Time{}),
+	}
+	for name, timer := range tests {
+		timer := timer
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := NewScheduler(1)
+			require.NoError(t, err)
+			assert.True(t, errors.Is(s.Schedule(timer, signalJob{name: "invalid"}), ErrInvalidDayTimer))
+		})
+	}
+}